@@ -0,0 +1,297 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/agent"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+
+	flag "github.com/spf13/pflag"
+)
+
+// envAgentChild marks the detached agent process, which receives its agentSpec over fd 3
+const envAgentChild = "GOMPW_AGENT_CHILD"
+
+func init() {
+	commands["agent"] = &command{args: "[lock|unlock|status|stop]", run: cmdAgent}
+}
+
+// agentSpec is handed from the prompting gompw to the detached agent process
+type agentSpec struct {
+	Fullname           string
+	Password           string
+	MasterPasswordSeed string
+	AlgorithmVersion   uint32
+	KeyID              string
+	Lifetime           time.Duration
+	IdleTimeout        time.Duration
+	Types              map[string]*config.TypeConfig // [types."name"], as the agent ignores gompw.toml
+}
+
+// agentReady is reported back by the detached agent once it is listening
+type agentReady struct {
+	Socket string
+	Error  string
+}
+
+// cmdAgent starts an agent holding the master key, or controls the one advertised via GOMPW_AUTH_SOCK
+func cmdAgent(mpw *mpw, args []string) {
+	if len(args) == 0 {
+		if os.Getenv(envAgentChild) != "" {
+			runAgentChild()
+		} else {
+			mpw.startAgent()
+		}
+		return
+	}
+	if len(args) != 1 {
+		fatal("agent takes at most a single sub-command")
+	}
+
+	c := agent.NewClientFromEnv()
+	if c == nil {
+		fatal(agent.EnvAuthSock + " is not set, start one with: eval $(gompw agent)")
+	}
+
+	var err error
+	switch args[0] {
+	case "lock":
+		err = c.Lock()
+	case "status":
+		var s *agent.Status
+		if s, err = c.Status(); err == nil {
+			printAgentStatus(s)
+		}
+	case "stop":
+		err = c.Stop()
+	case "unlock":
+		var s *agent.Status
+		if s, err = c.Status(); err != nil {
+			break
+		}
+		// for the identicon
+		mpw.Config.Fullname = s.Fullname
+		mpw.handlePassword()
+		err = c.Unlock(mpw.Config.Password)
+	default:
+		fatal("Unknown agent sub-command: " + args[0])
+	}
+	if err != nil {
+		fatal(err.Error())
+	}
+}
+
+// startAgent prompts for the master password and starts the agent, detached unless --foreground
+func (mpw *mpw) startAgent() {
+	mpw.handleFullname()
+	mpw.handlePassword()
+	mpw.handleConfigMerging()
+
+	spec := &agentSpec{
+		Fullname:           mpw.Config.Fullname,
+		Password:           mpw.Config.Password,
+		MasterPasswordSeed: mpw.Config.MasterPasswordSeed,
+		AlgorithmVersion:   mpw.algorithmVersion(),
+		KeyID:              mpw.Config.KeyID,
+		Lifetime:           mpw.agentLifetime,
+		IdleTimeout:        mpw.agentIdle,
+		Types:              mpw.cu.Types,
+	}
+	// the agent is long-lived, only the spec keeps the master password until Unlock()
+	mpw.destroy()
+
+	if mpw.agentForeground {
+		runAgent(spec, func(socket string, err error) {
+			if err != nil {
+				fatal(err.Error())
+			}
+			printAgentEnv(socket, os.Getpid())
+		})
+		return
+	}
+
+	specR, specW, err := os.Pipe()
+	if err != nil {
+		fatal(err.Error())
+	}
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	cmd, err := spawnDetached(envAgentChild, []string{"--ignoreUserConfig", "agent"}, specR, readyW)
+	if err != nil {
+		fatal(err.Error())
+	}
+	specR.Close()
+	readyW.Close()
+
+	err = json.NewEncoder(specW).Encode(spec)
+	specW.Close()
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	var ready agentReady
+	if err = json.NewDecoder(readyR).Decode(&ready); err != nil {
+		fatal("agent failed to start: " + err.Error())
+	}
+	if ready.Error != "" {
+		fatal(ready.Error)
+	}
+
+	printAgentEnv(ready.Socket, cmd.Process.Pid)
+}
+
+// runAgentChild is the detached agent process, see startAgent()
+func runAgentChild() {
+	var spec agentSpec
+	specR := os.NewFile(3, "spec")
+	readyW := os.NewFile(4, "ready")
+	if err := json.NewDecoder(specR).Decode(&spec); err != nil {
+		fatal(err.Error())
+	}
+	specR.Close()
+
+	report := func(socket string, err error) {
+		ready := agentReady{Socket: socket}
+		if err != nil {
+			ready.Error = err.Error()
+		}
+		json.NewEncoder(readyW).Encode(&ready)
+		readyW.Close()
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+
+	// before serving, as RegisterPasswordType() is not safe for concurrent use
+	if err := registerPasswordTypes(spec.Types); err != nil {
+		report("", err)
+	}
+
+	runAgent(&spec, report)
+}
+
+// runAgent derives the master key and serves it until stopped, ready is called once listening or upon failure
+func runAgent(spec *agentSpec, ready func(socket string, err error)) {
+	a := agent.New(spec.MasterPasswordSeed, spec.Fullname, spec.AlgorithmVersion, spec.KeyID)
+	a.Lifetime = spec.Lifetime
+	a.IdleTimeout = spec.IdleTimeout
+
+	err := a.Unlock(spec.Password)
+	spec.Password = ""
+	if err != nil {
+		ready("", err)
+		return
+	}
+
+	dir, socket, err := agent.SocketPath()
+	if err != nil {
+		ready("", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	l, err := agent.Listen(socket)
+	if err != nil {
+		ready("", err)
+		return
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		a.Close()
+	}()
+
+	ready(socket, nil)
+	if err = a.Serve(l); err != nil {
+		fatal(err.Error())
+	}
+}
+
+// agentPassword fetches the site password (and key ID) from the agent, if any, it returns false to fall back to deriving locally
+func (mpw *mpw) agentPassword(arg string) (string, string, bool) {
+	c := agent.NewClientFromEnv()
+	if c == nil || mpw.noAgent || flag.ShorthandLookup("f").Changed || flag.ShorthandLookup("d").Changed {
+		return "", "", false
+	}
+
+	mpw.handleSite(arg)
+	mpw.handleConfigMerging()
+
+	av := mpw.algorithmVersion()
+	resp, err := c.SitePassword(&agent.Request{
+		Fullname:           mpw.Config.Fullname,
+		MasterPasswordSeed: mpw.Config.MasterPasswordSeed,
+		AlgorithmVersion:   &av,
+		KeyID:              mpw.Config.KeyID,
+		Site:               mpw.Config.Site,
+		PasswordType:       mpw.Config.PasswordType,
+		PasswordPurpose:    mpw.Config.PasswordPurpose,
+		KeyContext:         mpw.Config.KeyContext,
+		Options:            crypto.NewPasswordOptions(mpw.Config),
+		Counter:            mpw.Config.Counter,
+	})
+	if err == crypto.ErrMasterKeyIDMismatch {
+		// refused as for a local derivation, --keyid-warn falls back to it
+		mpw.handleKeyIDError(err)
+	}
+	if err != nil {
+		log.Printf("[WARNING] agent: %s, deriving locally", err)
+		return "", "", false
+	}
+	mpw.Config.Fullname = resp.Fullname
+
+	return resp.Password, resp.KeyID, true
+}
+
+// printAgentEnv outputs the agent's environment, for use with: eval $(gompw agent)
+func printAgentEnv(socket string, pid int) {
+	fmt.Printf("%s=%s; export %s;\n", agent.EnvAuthSock, socket, agent.EnvAuthSock)
+	fmt.Printf("echo Agent pid %d;\n", pid)
+}
+
+func printAgentStatus(s *agent.Status) {
+	state := "unlocked"
+	if s.Locked {
+		state = "locked"
+	}
+	fmt.Printf("pid:       %d\n", s.PID)
+	fmt.Printf("fullname:  %s\n", s.Fullname)
+	fmt.Printf("algorithm: %d\n", s.AlgorithmVersion)
+	fmt.Printf("keyID:     %s\n", s.KeyID)
+	fmt.Printf("state:     %s\n", state)
+	if !s.Expires.IsZero() {
+		fmt.Printf("expires:   %s\n", s.Expires.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,61 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/askpass"
+)
+
+// askpassPROG selects askpass mode via a symlink, as SSH_ASKPASS and SUDO_ASKPASS only pass the prompt
+const askpassPROG = "gompw-askpass"
+
+func init() {
+	commands["askpass"] = &command{args: "prompt", run: cmdAskpass}
+}
+
+// cmdAskpass answers ssh and sudo password prompts, printing only the password
+//
+//   The site is the first configured [sites."name"] of: user@host, host
+//   e.g. SSH_ASKPASS=/usr/local/bin/gompw-askpass (a symlink to gompw)
+func cmdAskpass(mpw *mpw, args []string) {
+	if len(args) != 1 {
+		fatal("askpass requires the prompt")
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		fatal(err.Error())
+	}
+	prompt, err := askpass.Parse(args[0], hostname)
+	if err != nil {
+		fatal(fmt.Sprintf("%s: %q", err, args[0]))
+	}
+	mpw.Config.Site = mpw.configuredSite(prompt.Sites())
+
+	// stdout belongs to ssh/sudo
+	mpw.promptOnTTY()
+
+	pw, _ := mpw.sitePassword(mpw.Config.Site)
+	fmt.Println(pw)
+}
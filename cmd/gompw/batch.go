@@ -0,0 +1,115 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/batch"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+
+	flag "github.com/spf13/pflag"
+)
+
+// runBatch derives the passwords of the --batch site specs, scrypt'ing the master key only once
+func (mpw *mpw) runBatch() {
+	if flag.NArg() != 0 {
+		fatal("--batch does not take a site")
+	}
+
+	var in io.Reader = os.Stdin
+	if mpw.batch == "-" {
+		pwGiven := flag.ShorthandLookup("f").Changed || flag.ShorthandLookup("d").Changed ||
+			flagDefaults(mpw.cu.Pinentry, mpw.Config.Pinentry) != ""
+		if !pwGiven && !isaTTY(os.Stdin.Fd()) {
+			fatal("--batch reading stdin requires the master password via -f, -d or --pinentry")
+		}
+	} else {
+		f, err := os.Open(mpw.batch)
+		if err != nil {
+			fatal(err.Error())
+		}
+		defer f.Close()
+		in = f
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+
+	// each line takes precedence over the flags, which take precedence over gompw.toml
+	flags := *mpw.Config
+	mpw.handleConfigMerging()
+
+	mk, err := mpw.masterKey(mpw.algorithmVersion())
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err = mk.VerifyID(mpw.Config.KeyID); err != nil {
+		mpw.handleKeyIDError(err)
+	}
+
+	failed, err := batch.Run(in, os.Stdout, func(spec *batch.Spec) (string, error) {
+		// otherwise gompw.toml's site would be used
+		if spec.Site == "" {
+			return "", crypto.ErrSiteEmpty
+		}
+
+		c := &config.MPConfig{
+			Site:            spec.Site,
+			Counter:         spec.Counter,
+			PasswordType:    spec.PasswordType,
+			PasswordPurpose: spec.PasswordPurpose,
+			KeyContext:      spec.KeyContext,
+		}
+		c.Merge(&flags)
+		mpw.mergeConfig(c, spec.Counter != 0 || isFlagGiven("c"))
+
+		spec.Counter = c.Counter
+		spec.PasswordType = c.PasswordType
+		spec.PasswordPurpose = c.PasswordPurpose
+		spec.KeyContext = c.KeyContext
+
+		return mpw.configPassword(c)
+	})
+	if err != nil {
+		fatal(err.Error())
+	}
+	if failed > 0 {
+		fatal(fmt.Sprintf("%d batch line(s) failed", failed))
+	}
+}
+
+// configPassword derives the password of the merged c, see mergeConfig()
+func (mpw *mpw) configPassword(c *config.MPConfig) (string, error) {
+	version := crypto.AlgorithmVersionCurrent.Version()
+	if c.AlgorithmVersion != nil {
+		version = *c.AlgorithmVersion
+	}
+	mk, err := mpw.masterKey(version)
+	if err != nil {
+		return "", err
+	}
+
+	return mk.SitePasswordEX(c.PasswordType, crypto.NewPasswordOptions(c), c.Site, c.Counter, c.PasswordPurpose, c.KeyContext)
+}
@@ -0,0 +1,105 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/breach"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+)
+
+func init() {
+	commands["breach-check"] = &command{args: "[site...]", run: cmdBreachCheck}
+}
+
+// cmdBreachCheck looks up the passwords of the given sites, or all [sites."name"] of gompw.toml,
+// within the offline breach corpus of --breach-corpus.
+//
+//   Only the sites are reported, a breached site's counter should be bumped (see -c).
+func cmdBreachCheck(mpw *mpw, args []string) {
+	names := args
+	if len(names) == 0 {
+		for name := range mpw.cu.Sites {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		fatal("breach-check requires a site, or [sites.\"site\"] in gompw.toml")
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+
+	// each site takes precedence over the flags, which take precedence over gompw.toml
+	flags := *mpw.Config
+	mpw.handleConfigMerging()
+
+	if mpw.Config.BreachCorpus == "" {
+		fatal("breach-check requires --breach-corpus, or breachCorpus in gompw.toml")
+	}
+	corpus, err := breach.Open(mpw.Config.BreachCorpus)
+	if err != nil {
+		fatal(fmt.Sprintf("breach corpus: %s", err))
+	}
+	defer corpus.Close()
+
+	mk, err := mpw.masterKey(mpw.algorithmVersion())
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err = mk.VerifyID(mpw.Config.KeyID); err != nil {
+		mpw.handleKeyIDError(err)
+	}
+
+	breached := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, name := range names {
+		c := &config.MPConfig{Site: name}
+		c.Merge(&flags)
+		mpw.mergeConfig(c, isFlagGiven("c"))
+
+		status := "ok"
+		pw, err := mpw.configPassword(c)
+		if err == nil {
+			var count int64
+			if count, err = corpus.Count(pw); count > 0 {
+				status = fmt.Sprintf("BREACHED (%d times), bump its counter", count)
+				breached++
+			}
+		}
+		if err != nil {
+			status = fmt.Sprintf("(%s)", err)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", c.Site, c.Counter, c.PasswordType, status)
+	}
+	if err = tw.Flush(); err != nil {
+		fatal(err.Error())
+	}
+
+	if breached > 0 {
+		fatal(fmt.Sprintf("%d site(s) breached", breached))
+	}
+}
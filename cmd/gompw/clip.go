@@ -0,0 +1,143 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/clipboard"
+	"github.com/TerraTech/go-MasterPassword/pkg/common"
+
+	flag "github.com/spf13/pflag"
+)
+
+// envClipChild marks the detached process restoring the clipboard, which receives its clipSpec over fd 3
+const envClipChild = "GOMPW_CLIP_CHILD"
+
+// clipSpec is handed to the detached process restoring the clipboard, the terminal (OSC 52) is handed over as fd 4
+type clipSpec struct {
+	Copy     string
+	Paste    string
+	Digest   string // of the password, see clipboard.Restore()
+	Previous string
+	Tmux     bool
+	Timeout  time.Duration
+}
+
+// newClipboard returns the helper command Clipboard, else OSC 52 written to tty
+func newClipboard(spec *clipSpec, tty *os.File) (clipboard.Clipboard, error) {
+	if spec.Copy != "" {
+		return clipboard.NewCommand(spec.Copy, spec.Paste)
+	}
+
+	return clipboard.NewOSC52(tty, spec.Tmux), nil
+}
+
+// clipPassword copies pw to the clipboard, restoring (or clearing) it in the background after the timeout
+func (mpw *mpw) clipPassword(pw string) {
+	spec := &clipSpec{
+		Digest:  clipboard.Digest(pw),
+		Tmux:    os.Getenv("TMUX") != "",
+		Timeout: common.DefaultClipboardTimeout * time.Second,
+	}
+	if cc := mpw.Config.Clipboard; cc != nil {
+		spec.Copy = cc.Copy
+		spec.Paste = cc.Paste
+		if cc.Timeout != 0 {
+			spec.Timeout = time.Duration(cc.Timeout) * time.Second
+		}
+	}
+	if flag.Lookup("clip-timeout").Changed {
+		spec.Timeout = mpw.clipTimeout
+	}
+
+	var tty *os.File
+	var err error
+	if spec.Copy == "" {
+		if tty, err = os.OpenFile("/dev/tty", os.O_WRONLY, 0); err != nil {
+			fatal("OSC 52 requires a terminal, otherwise configure a [clipboard] copy command: " + err.Error())
+		}
+		defer tty.Close()
+	}
+	c, err := newClipboard(spec, tty)
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	if spec.Previous, err = c.Paste(); err != nil && err != clipboard.ErrPasteUnsupported {
+		debug("clipboard: " + err.Error())
+	}
+	if err = c.Copy(pw); err != nil {
+		fatal(err.Error())
+	}
+
+	if spec.Timeout <= 0 {
+		fmt.Fprintf(os.Stderr, "Copied %s's password for %s to the clipboard\n", mpw.Config.Fullname, mpw.Config.Site)
+		return
+	}
+	action := "clearing"
+	if spec.Previous != "" {
+		action = "restoring"
+	}
+	fmt.Fprintf(os.Stderr, "Copied %s's password for %s to the clipboard, %s in %s\n", mpw.Config.Fullname, mpw.Config.Site, action, spec.Timeout)
+
+	specR, specW, err := os.Pipe()
+	if err != nil {
+		fatal(err.Error())
+	}
+	files := []*os.File{specR}
+	if tty != nil {
+		files = append(files, tty)
+	}
+	if _, err = spawnDetached(envClipChild, nil, files...); err != nil {
+		fatal(err.Error())
+	}
+	specR.Close()
+
+	err = json.NewEncoder(specW).Encode(spec)
+	specW.Close()
+	if err != nil {
+		fatal(err.Error())
+	}
+}
+
+// runClipChild is the detached process restoring the clipboard, see clipPassword()
+func runClipChild() {
+	var spec clipSpec
+	specR := os.NewFile(3, "spec")
+	if err := json.NewDecoder(specR).Decode(&spec); err != nil {
+		fatal(err.Error())
+	}
+	specR.Close()
+
+	c, err := newClipboard(&spec, os.NewFile(4, "tty"))
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	time.Sleep(spec.Timeout)
+	if err = clipboard.Restore(c, spec.Digest, spec.Previous); err != nil {
+		fatal(err.Error())
+	}
+}
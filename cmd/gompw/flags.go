@@ -27,10 +27,16 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/TerraTech/go-MasterPassword/pkg/common"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
 	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/output"
+	"github.com/TerraTech/go-MasterPassword/pkg/pinentry"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
 
 	flag "github.com/spf13/pflag"
 )
@@ -48,6 +54,9 @@ func (mpw *mpw) getResponse(prompt, errMsg string) string {
 }
 
 func (mpw *mpw) handleFullname() {
+	if mpw.Config.Fullname == "" {
+		mpw.Config.Fullname = mpw.cu.Fullname
+	}
 	if mpw.Config.Fullname == "" {
 		mpw.Config.Fullname = mpw.getResponse("Your full name: ", "Fullname must be specified")
 	}
@@ -62,7 +71,8 @@ func (mpw *mpw) handlePassword() {
 	// Priority:
 	// 1) -f
 	// 2) -d
-	// 3) stdin
+	// 3) pinentry
+	// 4) stdin
 	var errNoPassword = "Password must be specified"
 	if flag.ShorthandLookup("f").Changed || flag.ShorthandLookup("d").Changed {
 		if flag.ShorthandLookup("f").Changed {
@@ -90,62 +100,159 @@ func (mpw *mpw) handlePassword() {
 		if mpw.Config.Password == "" {
 			fatal(errNoPassword)
 		}
+	} else if program := flagDefaults(mpw.cu.Pinentry, mpw.Config.Pinentry); program != "" {
+		debug("pwInput: pinentry")
+		desc := fmt.Sprintf("Please enter the master password for %s", mpw.Config.Fullname)
+		mpw.Config.Password, err = pinentry.GetPassword(program, PROG, desc, "Master password:")
+		if err != nil {
+			fatal(err.Error())
+		}
+		if mpw.Config.Password == "" {
+			fatal(errNoPassword)
+		}
+		mpw.printIdenticon()
 	} else {
 		debug("pwInput: stdin")
 		mpw.Config.Password = mpw.getResponse("Your master password: ", errNoPassword)
+		mpw.printIdenticon()
+	}
+}
+
+// handleKeyIDError refuses a mismatching master key ID, unless --keyid-warn is in effect
+func (mpw *mpw) handleKeyIDError(err error) {
+	if err == crypto.ErrMasterKeyIDMismatch && mpw.keyIDWarn {
+		log.Printf("[WARNING] %s", err)
+		return
 	}
+
+	fatal(err.Error())
 }
 
-func (mpw *mpw) handleSite() {
+func (mpw *mpw) handleSite(arg string) {
+	// already handled, e.g. when falling back from the agent
+	if mpw.Config.Site != "" {
+		return
+	}
+
 	// handle site
-	site := flagDefaults("", flag.Arg(0), os.Getenv("MP_SITE"))
+	site := flagDefaults("", arg, os.Getenv("MP_SITE"))
 	if site == "" {
 		site = mpw.getResponse("Site name: ", "Site must be specified")
 	}
 	mpw.Config.Site = site
 }
 
-func (mpw *mpw) handleUserConfigLoading(configFile string, dump bool) {
+func (mpw *mpw) handleUserConfigLoading(configFile string, dump bool, permMode config.PermMode) {
 	if dump {
 		mpw.cu.SetDump(dump)
 	}
+	mpw.cu.SetPermMode(permMode)
 
 	err := mpw.cu.LoadConfig(configFile)
 	if err != nil {
 		fatal(err.Error())
 	}
+	configureDebug(mpw.cu.Debug)
 
-	// prime MasterPW struct with user configFile settings
-	mpw.Config.Merge(mpw.cu)
+	// [types."name"] are validated when loaded, rather than when first used
+	if err = registerPasswordTypes(mpw.cu.Types); err != nil {
+		fatal(err.Error())
+	}
+}
+
+// registerPasswordTypes registers the [types."name"] tables, in order of their name
+func registerPasswordTypes(types map[string]*config.TypeConfig) error {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t := types[name]
+		if err := crypto.RegisterPasswordType(name, t.Templates, t.Classes); err != nil {
+			return fmt.Errorf("gompw config 'types.\"%s\"': %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// handleConfigMerging primes MasterPW.Config once the site is known.
+//
+//   flags <=merge== [sites."site"] <=merge== gompw.toml <=merge== defaults
+func (mpw *mpw) handleConfigMerging() {
+	mpw.mergeConfig(mpw.Config, isFlagGiven("c"))
+}
+
+// mergeConfig completes c for its site, counterGiven prevents the counter from being reset
+func (mpw *mpw) mergeConfig(c *config.MPConfig, counterGiven bool) {
+	defaults := config.NewMPConfig()
+	defaults.PasswordPurpose = common.DefaultPasswordPurpose
+
+	c.Merge(mpw.cu.SiteConfig(c.Site))
+	c.Merge(mpw.cu)
+	c.Merge(defaults)
+
+	// only 'auth' supports a counter > 1, so don't inherit a global counter for the others
+	if !counterGiven {
+		token, err := crypto.PasswordPurposeToToken(c.PasswordPurpose)
+		if err == nil && token != crypto.PasswordPurposeAuthentication {
+			c.Counter = common.DefaultCounter
+		}
+	}
 }
 
 func handleFlags(mpw *mpw) {
 	var configFile string
 	var err error
+	var flagAlgorithmVersion uint32
 	var flagDumpConfig bool
+	var flagFixPerms bool
 	var flagListPasswordTypes bool
+	var flagOutput string
+	var flagPolicy string
 	var flagShowVersion bool
+	var flagStats bool
+	var flagWords config.WordsConfig
+	var flagWordSeparator string
 	var ignoreConfigFile bool
 
 	/* Flow of config for standard usage
 	 *  (NOTE: MasterPW.(priv-members) has full range of setters for advanced usage
 	 *
 	 *   MasterPW.(priv-members) <= MasterPW.Config (flag set) <=merge== MPConfig (userConfig file)
+	 *
+	 *   Flags only take precedence when given (or via their MP_* env), see handleConfigMerging()
 	 */
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "usage: %s [flags] site\n", PROG)
+		fmt.Fprintf(os.Stderr, "       %s [flags] --batch[=file]\n", PROG)
+		for _, name := range commandNames() {
+			fmt.Fprintf(os.Stderr, "       %s [flags] %s %s\n", PROG, name, commands[name].args)
+		}
 		flag.PrintDefaults()
 		fmt.Println("\n==Environment Variables==")
-		fmt.Println("  MP_CONFIGFILE   | The user configuration file (see -C)")
-		//             MP_DEBUG
-		//             MP_DUMP
-		fmt.Println("  MP_FULLNAME     | The full name of the user (see -u)")
-		fmt.Println("  MP_PWPURPOSE    | The password purpose (see -p)")
-		fmt.Println("  MP_PWTYPE       | The password type (see -t)")
-		fmt.Println("  MP_SEED         | The master password seed (see -S)")
-		fmt.Println("  MP_SITE         | The site for generated password")
-		fmt.Println("  MP_SITECOUNTER  | The default site counter value (see -c)")
+		fmt.Println("  GOMPW_AUTH_SOCK  | The agent's socket (see 'agent' command)")
+		fmt.Println("  MP_ALGORITHM     | The algorithm version (see -a)")
+		fmt.Println("  MP_BREACH_CORPUS | The offline breach corpus (see --breach-corpus)")
+		fmt.Println("  MP_CONFIGFILE    | The user configuration file (see -C)")
+		fmt.Println("  MP_CONFIG_PERMS  | The user configuration file permission check: enforce, warn or fix (see --fix-perms)")
+		fmt.Println("  MP_CONTEXT       | The site key context (see --context)")
+		//              MP_DEBUG
+		//              MP_DEBUG_REDACT
+		//              MP_DEBUG_UNSAFE
+		//              MP_DUMP
+		fmt.Println("  MP_FULLNAME      | The full name of the user (see -u)")
+		fmt.Println("  MP_KEYID         | The expected master key ID (see --keyid)")
+		fmt.Println("  MP_OUTPUT        | The machine-readable output format (see -o)")
+		fmt.Println("  MP_PINENTRY      | The pinentry program (see --pinentry)")
+		fmt.Println("  MP_POLICY        | The site password policy (see --policy)")
+		fmt.Println("  MP_PWPURPOSE     | The password purpose (see -p)")
+		fmt.Println("  MP_PWTYPE        | The password type (see -t)")
+		fmt.Println("  MP_SEED          | The master password seed (see -S)")
+		fmt.Println("  MP_SITE          | The site for generated password")
+		fmt.Println("  MP_SITECOUNTER   | The default site counter value (see -c)")
 
 		fmt.Println("\n==User Config file location search order==")
 		fmt.Println("  1) ./gompw.toml")
@@ -153,20 +260,42 @@ func handleFlags(mpw *mpw) {
 		fmt.Println("  3) /etc/gompw.toml")
 	}
 
-	// "-v" reserved for '--verbose' if implemented
 	flag.BoolVarP(&flagDumpConfig, "dumpConfig", "D", false, "Dump the user configuration file and exit")
 	flag.BoolVarP(&flagListPasswordTypes, "listPasswordTypes", "l", false, "List valid Password Types")
+	flag.BoolVar(&flagStats, "stats", false, "List valid Password Types with their length, character set and entropy")
+	flag.BoolVarP(&mpw.verbose, "verbose", "v", false, "Verbose output, e.g. for 'types'")
 	flag.BoolVarP(&flagShowVersion, "version", "V", false, "Show version")
 	flag.BoolVarP(&ignoreConfigFile, "ignoreUserConfig", "I", false, "Ignore user configuration file")
+	flag.BoolVar(&flagFixPerms, "fix-perms", false, "Restrict the user configuration file to its owner (chmod go-rwx), if it holds the master password")
 	flag.BoolVar(&mpw.ssp, "ssp", false, "Shoulder Surfing Prevention by not echoing any terminal input")
+	flag.StringVarP(&flagOutput, "output", "o", os.Getenv("MP_OUTPUT"), "Machine-readable output: json, yaml, shell, env or template='{{.Site}} {{.Password}}'")
+	flag.BoolVar(&mpw.clip, "clip", false, "Copy the password to the clipboard instead of printing it (see [clipboard] in gompw.toml)")
+	flag.DurationVar(&mpw.clipTimeout, "clip-timeout", common.DefaultClipboardTimeout*time.Second, "Restore the clipboard after this long (0 disables)")
+	flag.StringVar(&mpw.batch, "batch", "", "Derive the passwords of the site specs read from the given file (default stdin)")
+	flag.Lookup("batch").NoOptDefVal = "-"
+	flag.BoolVar(&mpw.noAgent, "no-agent", false, "Do not use the agent advertised via GOMPW_AUTH_SOCK")
+	flag.BoolVar(&mpw.agentForeground, "foreground", false, "Run the agent in the foreground (see 'agent' command)")
+	flag.DurationVar(&mpw.agentLifetime, "agent-lifetime", 0, "Lock the agent this long after unlocking, e.g. 8h (0 disables)")
+	flag.DurationVar(&mpw.agentIdle, "agent-idle", 0, "Lock the agent after being idle this long, e.g. 15m (0 disables)")
+	flag.BoolVar(&mpw.keyIDWarn, "keyid-warn", false, "Only warn when the master key ID does not match (see --keyid)")
+	flag.StringVar(&mpw.Config.KeyID, "keyid", os.Getenv("MP_KEYID"), "Expected master key ID, refuses a mistyped master password (see 'keyid' command)")
 	flag.StringVarP(&configFile, "config", "C", "", "User configuration file override")
+	flag.StringVar(&mpw.Config.KeyContext, "context", os.Getenv("MP_CONTEXT"), "Site key context, e.g. the security question for '-p rec'")
 	flag.StringVarP(&mpw.Config.Fullname, "fullname", "u", os.Getenv("MP_FULLNAME"), "Fullname")
 	flag.StringVarP(&mpw.Config.MasterPasswordSeed, "mpseed", "S", flagDefaults(common.DefaultMasterPasswordSeed, os.Getenv("MP_SEED")), "Override the Master Password Seed")
 	flag.StringVarP(&mpw.Config.PasswordPurpose, "purpose", "p", flagDefaults(common.DefaultPasswordPurpose, os.Getenv("MP_PWPURPOSE")), flagHelp("p"))
 	flag.StringVarP(&mpw.Config.PasswordType, "pwtype", "t", flagDefaults(common.DefaultPasswordType, os.Getenv("MP_PWTYPE")), flagHelp("t"))
 	flag.StringVarP(&mpw.pwFile, "file", "f", "", "Read user's master password from given filename")
+	flag.StringVar(&mpw.Config.Pinentry, "pinentry", os.Getenv("MP_PINENTRY"), "Read user's master password via the given pinentry program")
+	flag.StringVar(&mpw.Config.BreachCorpus, "breach-corpus", os.Getenv("MP_BREACH_CORPUS"), "Offline Pwned Passwords range directory or sorted hash file (see 'breach-check' command)")
+	flag.Lookup("pinentry").NoOptDefVal = "pinentry"
 	flag.Uint32VarP(&mpw.Config.Counter, "counter", "c", flagDefaultCounter(os.Getenv("MP_SITECOUNTER")), "Site password counter value")
+	flag.Uint32VarP(&flagAlgorithmVersion, "algorithm", "a", flagDefaultAlgorithmVersion(os.Getenv("MP_ALGORITHM")), "Algorithm version (0-3), for sites created with older mpw clients")
 	flag.UintVarP(&mpw.fd, "fd", "d", 0, "Read user's master password from given file descriptor")
+	flag.Uint32Var(&flagWords.Count, "words", 0, "Number of words for the bip39 and diceware types (see [words] in gompw.toml)")
+	flag.StringVar(&flagWordSeparator, "word-separator", crypto.DefaultWordSeparator, "Separator between the words of the bip39 and diceware types")
+	flag.StringVar(&flagWords.Case, "word-case", "", "Case of the words of the bip39 and diceware types: lower, title or upper")
+	flag.StringVar(&flagPolicy, "policy", os.Getenv("MP_POLICY"), "Site password policy, e.g. 'min=8,max=16,require=digit+symbol,forbid=;' (see 'policy' command)")
 
 	flag.Parse()
 
@@ -175,16 +304,64 @@ func handleFlags(mpw *mpw) {
 		os.Exit(0)
 	}
 
-	if flagListPasswordTypes {
-		listPasswordTypes(mpw)
-		os.Exit(0)
-	}
-
 	// -d and -f are mutually exclusive
 	if flag.ShorthandLookup("d").Changed && flag.ShorthandLookup("f").Changed {
 		fatal("-d and -f are mutually exclusive.")
 	}
 
+	if err = crypto.ValidateKeyID(mpw.Config.KeyID); err != nil {
+		fatal(err.Error())
+	}
+
+	if mpw.clip && (flagOutput != "" || mpw.batch != "") {
+		fatal("--clip is mutually exclusive with -o and --batch")
+	}
+
+	// --batch writes its own line format, see pkg/batch
+	if flagOutput != "" && mpw.batch != "" {
+		fatal("-o is mutually exclusive with --batch")
+	}
+
+	if flagOutput != "" {
+		if mpw.output, err = output.New(flagOutput); err != nil {
+			fatal(err.Error())
+		}
+	}
+
+	// flag defaults would otherwise mask gompw.toml, they are set by handleConfigMerging()
+	if !isFlagGiven("S") {
+		mpw.Config.MasterPasswordSeed = ""
+	}
+	if !isFlagGiven("p") {
+		mpw.Config.PasswordPurpose = ""
+	}
+	if !isFlagGiven("t") {
+		mpw.Config.PasswordType = ""
+	}
+	if !isFlagGiven("c") {
+		mpw.Config.Counter = 0
+	}
+	if isFlagGiven("a") {
+		if err = crypto.ValidateAlgorithmVersion(flagAlgorithmVersion); err != nil {
+			fatal(err.Error())
+		}
+		mpw.Config.AlgorithmVersion = &flagAlgorithmVersion
+	}
+
+	if flagPolicy != "" {
+		if mpw.Config.Policy, err = policy.Parse(flagPolicy); err != nil {
+			fatal(err.Error())
+		}
+	}
+
+	// only override the [words] fields that were given
+	if flag.Lookup("word-separator").Changed {
+		flagWords.Separator = &flagWordSeparator
+	}
+	if flagWords.Separator != nil || flagWords.Case != "" || flagWords.Count != 0 {
+		mpw.Config.Words = &flagWords
+	}
+
 	// -I and -C are mutually exclusive
 	if flag.ShorthandLookup("I").Changed && flag.ShorthandLookup("C").Changed {
 		fatal("-I and -C are mutually exclusive.")
@@ -206,14 +383,39 @@ func handleFlags(mpw *mpw) {
 		}
 	}
 
+	// a config holding the master password must not be accessible by others
+	permMode, err := config.ParsePermMode(os.Getenv("MP_CONFIG_PERMS"))
+	if err != nil {
+		fatal(fmt.Sprintf("MP_CONFIG_PERMS: %s", err))
+	}
+	if flagFixPerms {
+		permMode = config.PermsFix
+	}
+
 	// prime the pump
 	if !ignoreConfigFile {
-		mpw.handleUserConfigLoading(configFile, flagDumpConfig)
+		mpw.handleUserConfigLoading(configFile, flagDumpConfig, permMode)
 	}
 
-	mpw.handleFullname()
-	mpw.handlePassword()
-	mpw.handleSite()
+	// after loading, so the custom password types are listed
+	if flagListPasswordTypes || flagStats {
+		listPasswordTypes(mpw, flagStats || mpw.verbose)
+		os.Exit(0)
+	}
+}
+
+// flagEnvs maps the flags to their MP_* env override
+var flagEnvs = map[string]string{
+	"S": "MP_SEED",
+	"a": "MP_ALGORITHM",
+	"c": "MP_SITECOUNTER",
+	"p": "MP_PWPURPOSE",
+	"t": "MP_PWTYPE",
+}
+
+// isFlagGiven reports if the flag was specified either explicitly or via its MP_* env
+func isFlagGiven(shorthand string) bool {
+	return flag.ShorthandLookup(shorthand).Changed || os.Getenv(flagEnvs[shorthand]) != ""
 }
 
 func flagDefaults(_default string, overrides ...string) string {
@@ -227,14 +429,22 @@ func flagDefaults(_default string, overrides ...string) string {
 }
 
 func flagDefaultCounter(override string) uint32 {
+	return flagDefaultUint32("MP_SITECOUNTER", override, common.DefaultCounter)
+}
+
+func flagDefaultAlgorithmVersion(override string) uint32 {
+	return flagDefaultUint32("MP_ALGORITHM", override, crypto.AlgorithmVersionCurrent.Version())
+}
+
+func flagDefaultUint32(envName, override string, _default uint32) uint32 {
 	if override != "" {
-		mpsc, err := strconv.Atoi(override)
+		v, err := strconv.ParseUint(override, 10, 32)
 		if err != nil {
-			log.Print("Invalid value specified for MP_SITECOUNTER")
+			log.Printf("Invalid value specified for %s", envName)
 			log.Fatal(err.Error())
 		}
-		return uint32(mpsc)
+		return uint32(v)
 	}
 
-	return common.DefaultCounter
+	return _default
 }
@@ -0,0 +1,70 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"os"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/gitcredential"
+)
+
+func init() {
+	commands["git-credential"] = &command{args: "get|store|erase", run: cmdGitCredential}
+}
+
+// cmdGitCredential is a git credential helper, e.g. git config credential.helper 'gompw git-credential'
+//
+//   The site is the first configured [sites."name"] of: host/path, host:port, host
+//   The username is git's, else the site's loginName.
+//   store and erase are no-ops, as the passwords are derived rather than stored.
+func cmdGitCredential(mpw *mpw, args []string) {
+	if len(args) != 1 {
+		fatal("git-credential requires one of: get, store, erase")
+	}
+
+	c, err := gitcredential.Read(os.Stdin)
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	// store, erase and (as per gitcredentials(7)) unknown actions are no-ops
+	if args[0] != "get" {
+		return
+	}
+
+	sites, err := c.Sites()
+	if err != nil {
+		fatal(err.Error())
+	}
+	mpw.Config.Site = mpw.configuredSite(sites)
+
+	// stdin/stdout belong to git
+	mpw.promptOnTTY()
+
+	c.Password, _ = mpw.sitePassword(mpw.Config.Site)
+	if c.Username == "" {
+		c.Username = mpw.Config.LoginName
+	}
+
+	if err = c.Write(os.Stdout); err != nil {
+		fatal(err.Error())
+	}
+}
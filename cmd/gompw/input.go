@@ -27,16 +27,41 @@ import (
 	"futurequest.net/FQgolibs/FQterm"
 )
 
+// prompts are read from promptIn and written to promptOut, see usePromptTTY()
+var (
+	promptIn  = os.Stdin
+	promptOut = os.Stderr
+)
+
+// usePromptTTY prompts on the controlling terminal, for when stdin/stdout are used by a protocol (e.g. git)
+func usePromptTTY() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	promptIn, promptOut = tty, tty
+
+	return nil
+}
+
+// promptOnTTY prompts on the controlling terminal, where the master password must not echo
+func (mpw *mpw) promptOnTTY() {
+	if err := usePromptTTY(); err != nil {
+		debug("tty: " + err.Error())
+	}
+	mpw.ssp = true
+}
+
 func readInput(prompt string, ssp bool) (string, error) {
 	var input string
 	var err error
 
-	fmt.Fprint(os.Stderr, prompt)
+	fmt.Fprint(promptOut, prompt)
 	if ssp {
-		input, err = FQterm.ReadPassword(os.Stdin)
-		fmt.Fprintln(os.Stderr)
+		input, err = FQterm.ReadPassword(promptIn)
+		fmt.Fprintln(promptOut)
 	} else {
-		input, err = FQterm.ReadInput(os.Stdin)
+		input, err = FQterm.ReadInput(promptIn)
 	}
 
 	return input, err
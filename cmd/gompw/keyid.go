@@ -0,0 +1,52 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+)
+
+func init() {
+	commands["keyid"] = &command{args: "", run: cmdKeyID}
+}
+
+// cmdKeyID shows the master key ID, for use with --keyid or gompw.toml's keyID
+func cmdKeyID(mpw *mpw, args []string) {
+	if len(args) != 0 {
+		fatal("keyid does not take any arguments")
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+	mpw.handleConfigMerging()
+
+	mk, err := crypto.NewMasterKey(mpw.Config.MasterPasswordSeed, mpw.Config.Fullname, mpw.Config.Password, mpw.algorithmVersion())
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err = mk.VerifyID(mpw.Config.KeyID); err != nil {
+		mpw.handleKeyIDError(err)
+	}
+
+	fmt.Println(mk.ID())
+}
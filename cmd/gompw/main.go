@@ -23,10 +23,14 @@ package main
 import (
 	"os"
 	"path"
+	"time"
 
 	"futurequest.net/FQgolibs/FQversion"
 	"github.com/TerraTech/go-MasterPassword/pkg/config"
 	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/output"
+
+	flag "github.com/spf13/pflag"
 )
 
 // These vars are used for building the version string, with some injected via Makefile
@@ -39,10 +43,56 @@ var (
 
 type mpw struct {
 	*crypto.MasterPW
-	cu     *config.MPConfig // (MP)Config User, loaded from .toml files
-	fd     uint
-	pwFile string
-	ssp    bool
+	cu              *config.MPConfig // (MP)Config User, loaded from .toml files
+	fd              uint
+	pwFile          string
+	ssp             bool
+	verbose         bool
+	keyIDWarn       bool
+	noAgent         bool
+	agentForeground bool
+	agentLifetime   time.Duration
+	agentIdle       time.Duration
+	batch           string           // batch file, "-" for stdin
+	output          output.Formatter // nil for human readable output
+	clip            bool
+	clipTimeout     time.Duration
+	mks             map[uint32]*crypto.MasterKey // cached by algorithm version, see masterKey()
+}
+
+// algorithmVersion returns the configured algorithm version, or the current one if unset
+func (mpw *mpw) algorithmVersion() uint32 {
+	if mpw.Config.AlgorithmVersion != nil {
+		return *mpw.Config.AlgorithmVersion
+	}
+
+	return crypto.AlgorithmVersionCurrent.Version()
+}
+
+// destroy wipes the master password and the cached master keys, see crypto.Secret
+func (mpw *mpw) destroy() {
+	for version, mk := range mpw.mks {
+		mk.Destroy()
+		delete(mpw.mks, version)
+	}
+	mpw.MasterPW.Destroy()
+}
+
+// masterKey returns the MasterKey for the given algorithm version, deriving it only once
+func (mpw *mpw) masterKey(version uint32) (*crypto.MasterKey, error) {
+	if mk, ok := mpw.mks[version]; ok {
+		return mk, nil
+	}
+	mk, err := crypto.NewMasterKey(mpw.Config.MasterPasswordSeed, mpw.Config.Fullname, mpw.Config.Password, version)
+	if err != nil {
+		return nil, err
+	}
+	if mpw.mks == nil {
+		mpw.mks = make(map[uint32]*crypto.MasterKey)
+	}
+	mpw.mks[version] = mk
+
+	return mk, nil
 }
 
 func main() {
@@ -51,12 +101,54 @@ func main() {
 		cu:       &config.MPConfig{},
 	}
 
+	if os.Getenv(envClipChild) != "" {
+		runClipChild()
+		return
+	}
+
 	handleFlags(mpw)
+	defer mpw.destroy()
+
+	if PROG == askpassPROG {
+		cmdAskpass(mpw, flag.Args())
+		return
+	}
+
+	if cmd, ok := commands[flag.Arg(0)]; ok {
+		cmd.run(mpw, flag.Args()[1:])
+		return
+	}
+
+	if mpw.batch != "" {
+		mpw.runBatch()
+		return
+	}
 
-	mPassword, err := mpw.MasterPassword()
+	mPassword, keyID := mpw.sitePassword(flag.Arg(0))
+	printPassword(mpw, mPassword, keyID)
+}
+
+// sitePassword returns the site's password and master key ID, the site is prompted for if arg is ""
+func (mpw *mpw) sitePassword(arg string) (string, string) {
+	// an agent spares the master password prompt and scrypt
+	if mPassword, keyID, ok := mpw.agentPassword(arg); ok {
+		return mPassword, keyID
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+	mpw.handleSite(arg)
+	mpw.handleConfigMerging()
+
+	mk, err := mpw.MasterKey()
 	if err != nil {
 		fatal(err.Error())
 	}
+	defer mk.Destroy()
+	mPassword, err := mpw.SitePassword(mk)
+	if err != nil {
+		mpw.handleKeyIDError(err)
+	}
 
-	printPassword(mpw, mPassword)
+	return mPassword, mk.ID()
 }
@@ -0,0 +1,109 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/otp"
+)
+
+func init() {
+	commands["otp"] = &command{args: "site [code|enroll|hotp counter|hotp-enroll [counter]]", run: cmdOTP}
+}
+
+// cmdOTP stands in for an authenticator app, using an OTP secret derived from the site key.
+//
+//   code:         the current 6 digit code (default)
+//   enroll:       the base32 secret and otpauth:// URI, for enrolling the site's server
+//   hotp:         the 6 digit code for the given counter, for HOTP (RFC 4226) servers
+//   hotp-enroll:  the base32 secret and otpauth://hotp/ URI, starting at counter (default 0)
+//
+//   The secret is perturbed by counter (-c) and context (--context), but not by type or purpose.
+func cmdOTP(mpw *mpw, args []string) {
+	if len(args) < 1 || len(args) > 3 {
+		fatal("otp requires a site, optionally followed by one of: code, enroll, hotp counter, hotp-enroll [counter]")
+	}
+	mode := "code"
+	if len(args) > 1 {
+		mode = args[1]
+	}
+	var counter uint64
+	switch mode {
+	case "code", "enroll":
+		if len(args) == 3 {
+			fatal(fmt.Sprintf("otp: %s does not take a counter", mode))
+		}
+	case "hotp", "hotp-enroll":
+		if len(args) == 3 {
+			var err error
+			if counter, err = strconv.ParseUint(args[2], 10, 64); err != nil {
+				fatal(fmt.Sprintf("otp: invalid counter: %s", args[2]))
+			}
+		} else if mode == "hotp" {
+			fatal("otp: hotp requires a counter")
+		}
+	default:
+		fatal(fmt.Sprintf("otp: unknown mode: %s", mode))
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+	mpw.handleSite(args[0])
+	mpw.handleConfigMerging()
+
+	mk, err := mpw.MasterKey()
+	if err != nil {
+		fatal(err.Error())
+	}
+	defer mk.Destroy()
+	secret, err := mpw.OTPSecret(mk)
+	if err != nil {
+		mpw.handleKeyIDError(err)
+	}
+	totp := &otp.TOTP{Secret: secret}
+
+	account := mpw.Config.LoginName
+	if account == "" {
+		account = mpw.Config.Fullname
+	}
+
+	var code string
+	switch mode {
+	case "enroll":
+		fmt.Println(otp.Base32(secret))
+		fmt.Println(totp.URI(mpw.Config.Site, account))
+		return
+	case "hotp-enroll":
+		fmt.Println(otp.Base32(secret))
+		fmt.Println(otp.HOTPURI(secret, counter, otp.DefaultDigits, mpw.Config.Site, account))
+		return
+	case "hotp":
+		code, err = otp.HOTP(secret, counter, otp.DefaultDigits)
+	default:
+		code, err = totp.Code()
+	}
+	if err != nil {
+		fatal(err.Error())
+	}
+	fmt.Println(code)
+}
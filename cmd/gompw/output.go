@@ -24,6 +24,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/output"
 )
 
 const passwordTypeHelpIndent = 28
@@ -46,6 +49,8 @@ var helpMsg = map[string]string{
     i, pin      | 4 numbers
     n, name     | 9 letter name
     p, phrase   | 20 character sentence
+    bip39       | BIP39 mnemonic, 12 words (see --words)
+    diceware    | EFF long word list, 6 words (see --words)
 `,
 }
 
@@ -59,9 +64,62 @@ func flagHelp(opt string) string {
 	return strings.Replace(helpMsg[opt], "\n", "\n"+indention, -1)
 }
 
-func printPassword(mpw *mpw, pw string) {
+// printIdenticon allows the user to confirm at a glance that the master password was typed correctly
+func (mpw *mpw) printIdenticon() {
+	// the identicon leaks a few bits of the master password
+	if mpw.ssp {
+		return
+	}
+
+	identicon := crypto.Identicon(mpw.Config.Fullname, mpw.Config.Password)
+	if isaTTY(os.Stdout.Fd()) || promptOut != os.Stderr {
+		fmt.Fprintf(promptOut, "[ %s ]\n", identicon.ANSI())
+	} else {
+		fmt.Fprintf(promptOut, "[ %s ]\n", identicon)
+	}
+}
+
+// record returns the machine-readable record of the derived password
+func (mpw *mpw) record(pw, keyID string) *output.Record {
+	purpose := mpw.Config.PasswordPurpose
+	if token, err := crypto.PasswordPurposeToToken(purpose); err == nil {
+		purpose = token.Name()
+	}
+
+	return &output.Record{
+		Fullname:  mpw.Config.Fullname,
+		Site:      mpw.Config.Site,
+		LoginName: mpw.Config.LoginName,
+		Counter:   mpw.Config.Counter,
+		Type:      crypto.PasswordTypeName(mpw.Config.PasswordType),
+		Purpose:   purpose,
+		Context:   mpw.Config.KeyContext,
+		Algorithm: mpw.algorithmVersion(),
+		KeyID:     keyID,
+		Password:  pw,
+	}
+}
+
+func printPassword(mpw *mpw, pw, keyID string) {
+	// keep the password out of the terminal's scrollback
+	if mpw.clip {
+		mpw.clipPassword(pw)
+		return
+	}
+
+	if mpw.output != nil {
+		if err := mpw.output.Write(os.Stdout, mpw.record(pw, keyID)); err != nil {
+			fatal(err.Error())
+		}
+		return
+	}
+
 	if !mpw.ssp && isaTTY(os.Stdout.Fd()) {
-		fmt.Printf("%s's password for %s:\n", mpw.Config.Fullname, mpw.Config.Site)
+		if mpw.Config.LoginName != "" {
+			fmt.Printf("%s's password for %s (login: %s):\n", mpw.Config.Fullname, mpw.Config.Site, mpw.Config.LoginName)
+		} else {
+			fmt.Printf("%s's password for %s:\n", mpw.Config.Fullname, mpw.Config.Site)
+		}
 	}
 	fmt.Println(pw)
 }
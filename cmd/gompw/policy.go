@@ -0,0 +1,70 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+)
+
+func init() {
+	commands["policy"] = &command{args: "check [site]", run: cmdPolicy}
+}
+
+// cmdPolicy reports which password types comply with the site's policy, from --policy,
+// [sites."site".policy] or [policy].
+//
+//   Non-compliant template types are still usable, as their violating passwords are adjusted to the
+//   policy, which however changes them.
+func cmdPolicy(mpw *mpw, args []string) {
+	if len(args) < 1 || len(args) > 2 || args[0] != "check" {
+		fatal("policy requires: check [site]")
+	}
+	if len(args) == 2 {
+		mpw.Config.Site = args[1]
+	}
+	mpw.handleConfigMerging()
+
+	p := mpw.Config.Policy
+	if p == nil {
+		fatal("no policy given, see --policy or [sites.\"site\".policy] in gompw.toml")
+	}
+	fmt.Printf("policy: %s\n", p)
+
+	wf := crypto.NewWordFormat(mpw.Config.Words)
+	for _, name := range mpw.GetPasswordTypes() {
+		// skip the shortcodes
+		if crypto.PasswordTypeName(name) != name {
+			continue
+		}
+
+		status := "ok"
+		if err := crypto.CheckPasswordType(name, wf, p); err != nil {
+			status = err.Error()
+		}
+		marker := " "
+		if name == crypto.PasswordTypeName(mpw.Config.PasswordType) {
+			marker = "*"
+		}
+		fmt.Printf("%s %-12s %s\n", marker, name, status)
+	}
+}
@@ -0,0 +1,194 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"futurequest.net/FQgolibs/FQfile"
+	"github.com/TerraTech/go-MasterPassword/pkg/common"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/sites"
+)
+
+func init() {
+	commands["import"] = &command{args: "file.mpsites[.json] [gompw.toml]", run: cmdImport}
+	commands["export"] = &command{args: "file.mpsites[.json] [site]", run: cmdExport}
+}
+
+// configuredSite returns the first of the candidate sites having a [sites."name"] table, else the last candidate
+func (mpw *mpw) configuredSite(candidates []string) string {
+	for _, site := range candidates {
+		if _, ok := mpw.cu.Sites[site]; ok {
+			return site
+		}
+	}
+
+	return candidates[len(candidates)-1]
+}
+
+// cmdImport stores the sites of the given mpsites file as [sites."name"] tables, appended to the given
+// gompw config file, else the loaded one, else $HOME/.gompw.toml
+//
+//   Sites already having a table are left untouched, no passwords are derived.
+func cmdImport(mpw *mpw, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fatal("import requires an mpsites file and an optional gompw config file")
+	}
+
+	u, err := sites.Load(args[0])
+	if err != nil {
+		fatal(err.Error())
+	}
+
+	file := mpw.cu.ConfigFile
+	if len(args) == 2 {
+		file = args[1]
+	}
+	if file == "" {
+		file = filepath.Join(os.Getenv("HOME"), "."+common.DefaultConfigFilename)
+	}
+
+	tables := make(map[string]*config.SiteConfig, len(u.Sites))
+	for _, s := range u.Sites {
+		sc, err := importSite(s)
+		if err != nil {
+			log.Printf("[WARNING] import '%s': %s", s.Name, err)
+			continue
+		}
+		tables[s.Name] = sc
+	}
+
+	skipped, err := config.AppendSites(file, tables)
+	if err != nil {
+		fatal(err.Error())
+	}
+	for _, name := range skipped {
+		log.Printf("[WARNING] import '%s': already has a [sites.\"%s\"] table in %s", name, name, file)
+	}
+	fmt.Printf("Imported %d of %d site(s) into %s\n", len(tables)-len(skipped), len(u.Sites), file)
+}
+
+// importSite returns the [sites."name"] table of s, fully specified so it doesn't inherit gompw.toml's globals
+func importSite(s *sites.Site) (*config.SiteConfig, error) {
+	pt, err := s.Type.PasswordType()
+	if err != nil {
+		return nil, err
+	}
+	algorithmVersion := s.Algorithm
+
+	return &config.SiteConfig{
+		PasswordType:     pt,
+		LoginName:        s.LoginName,
+		AlgorithmVersion: &algorithmVersion,
+		Counter:          s.Counter,
+	}, nil
+}
+
+// cmdExport adds (or updates) the site within the given mpsites file, creating it if needed
+func cmdExport(mpw *mpw, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fatal("export requires an mpsites file and an optional site")
+	}
+	file := args[0]
+	var site string
+	if len(args) == 2 {
+		site = args[1]
+	}
+
+	var u *sites.User
+	var err error
+	if FQfile.IsFile(file) {
+		if u, err = sites.Load(file); err != nil {
+			fatal(err.Error())
+		}
+		if mpw.Config.Fullname == "" {
+			mpw.Config.Fullname = u.Fullname
+		}
+	}
+	mpw.handleFullname()
+	mpw.handleSite(site)
+	mpw.handleConfigMerging()
+	if u == nil {
+		u = sites.NewUser(mpw.Config.Fullname, mpw.algorithmVersion())
+		u.Version = crypto.MpwSeries
+	}
+	if mpw.Config.KeyID != "" {
+		u.KeyID = mpw.Config.KeyID
+	}
+
+	s, err := exportSite(mpw, u.Site(mpw.Config.Site))
+	if err != nil {
+		fatal(err.Error())
+	}
+	if err = u.AddSite(s); err != nil {
+		fatal(err.Error())
+	}
+	u.ExportDate = time.Now().UTC().Truncate(time.Second)
+
+	if err = sites.Save(file, u); err != nil {
+		fatal(err.Error())
+	}
+}
+
+// exportSite returns s updated with the current site settings, or a new Site if s is nil
+func exportSite(mpw *mpw, s *sites.Site) (*sites.Site, error) {
+	if s == nil {
+		s = &sites.Site{
+			Name:    mpw.Config.Site,
+			Type:    sites.ResultTypeTemplateLong,
+			Counter: 1,
+		}
+	}
+	s.Algorithm = mpw.algorithmVersion()
+	if mpw.Config.LoginName != "" {
+		s.LoginName = mpw.Config.LoginName
+	}
+	s.LastUsed = time.Now().UTC().Truncate(time.Second)
+
+	purpose, err := crypto.PasswordPurposeToToken(mpw.Config.PasswordPurpose)
+	if err != nil {
+		return nil, err
+	}
+
+	switch purpose {
+	case crypto.PasswordPurposeAuthentication:
+		if s.Type, err = sites.PasswordTypeToResultType(mpw.Config.PasswordType); err != nil {
+			return nil, err
+		}
+		s.Counter = mpw.Config.Counter
+	case crypto.PasswordPurposeRecovery:
+		// questions are recorded by their keyContext
+		for _, q := range s.Questions {
+			if q.Keyword == mpw.Config.KeyContext {
+				return s, nil
+			}
+		}
+		s.Questions = append(s.Questions, &sites.Question{Keyword: mpw.Config.KeyContext})
+	}
+
+	return s, nil
+}
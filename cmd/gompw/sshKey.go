@@ -0,0 +1,82 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func init() {
+	commands["ssh-key"] = &command{args: "site [private|public]", run: cmdSSHKey}
+}
+
+// cmdSSHKey emits the site's Ed25519 SSH key, which is derived rather than stored,
+// thus a lost key can be regenerated from the master password alone.
+//
+//   private: OpenSSH private key, e.g. gompw ssh-key host private > ~/.ssh/id_ed25519
+//   public:  authorized_keys line, e.g. gompw ssh-key host public > ~/.ssh/id_ed25519.pub
+//   default: both of the above
+//
+//   The key is perturbed by counter (-c) and context (--context), but not by type or purpose.
+func cmdSSHKey(mpw *mpw, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		fatal("ssh-key requires a site, optionally followed by one of: private, public")
+	}
+	private, public := true, true
+	if len(args) == 2 {
+		switch args[1] {
+		case "private":
+			public = false
+		case "public":
+			private = false
+		default:
+			fatal(fmt.Sprintf("ssh-key: unknown format: %s", args[1]))
+		}
+	}
+
+	mpw.handleFullname()
+	mpw.handlePassword()
+	mpw.handleSite(args[0])
+	mpw.handleConfigMerging()
+
+	mk, err := mpw.MasterKey()
+	if err != nil {
+		fatal(err.Error())
+	}
+	defer mk.Destroy()
+	key, err := mpw.SSHKey(mk)
+	if err != nil {
+		mpw.handleKeyIDError(err)
+	}
+	defer key.Destroy()
+
+	comment := mpw.Config.Site
+	if mpw.Config.LoginName != "" {
+		comment = mpw.Config.LoginName + "@" + comment
+	}
+	if private {
+		os.Stdout.Write(key.PrivateKeyPEM(comment))
+	}
+	if public {
+		fmt.Print(key.AuthorizedKey(comment))
+	}
+}
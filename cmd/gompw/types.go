@@ -0,0 +1,34 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package main
+
+func init() {
+	commands["types"] = &command{args: "[--verbose]", run: cmdTypes}
+}
+
+// cmdTypes lists the valid password types, including the custom ones of gompw.toml
+func cmdTypes(mpw *mpw, args []string) {
+	if len(args) != 0 {
+		fatal("types takes no arguments, see --verbose")
+	}
+
+	listPasswordTypes(mpw, mpw.verbose)
+}
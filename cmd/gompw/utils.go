@@ -21,36 +21,144 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"os"
+	"os/exec"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"futurequest.net/FQgolibs/FQversion"
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	dbg "github.com/TerraTech/go-MasterPassword/pkg/debug"
 	"golang.org/x/crypto/ssh/terminal"
 )
 
-var (
-	mpDebug = os.Getenv("MP_DEBUG") != ""
-)
+// debugScope keeps gompw's own debug lines in scope, as any MP_DEBUG or [debug] enables them
+const debugScope = "cmd/gompw"
+
+func init() {
+	if os.Getenv("MP_DEBUG") != "" {
+		dbg.NewDebug().SetFilename(debugScope)
+	}
+}
 
+// debug outputs msg via pkg/debug, if MP_DEBUG or gompw.toml's [debug] is in effect for its level
 func debug(msg string) {
-	if mpDebug {
-		log.Printf("[DEBUG] %s", msg)
+	dbg.NewDebug().LogDepth(1, dbg.LevelDebug, msg)
+}
+
+// configureDebug applies gompw.toml's [debug], MP_DEBUG takes precedence
+func configureDebug(c *config.DebugConfig) {
+	if c == nil || os.Getenv("MP_DEBUG") != "" {
+		return
+	}
+
+	err := dbg.NewDebug().Configure(&dbg.Config{Scope: c.Scope, Level: c.Level, Format: c.Format, Output: c.Output})
+	if err != nil {
+		fatal(fmt.Sprintf("gompw config 'debug': %s", err))
 	}
+	dbg.NewDebug().SetFilename(debugScope)
 }
 
 func fatal(msg string) {
 	log.Fatalf("[Fatal] %s", msg)
 }
 
+// spawnDetached re-executes gompw in the background, marked via env and handed files as fd 3 onwards
+func spawnDetached(env string, args []string, files ...*os.File) (*exec.Cmd, error) {
+	self, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(self, args...)
+	cmd.Env = append(os.Environ(), env+"=1")
+	cmd.ExtraFiles = files
+	detach(cmd)
+
+	return cmd, cmd.Start()
+}
+
 func isaTTY(fd uintptr) bool {
 	return terminal.IsTerminal(int(fd))
 }
 
-func listPasswordTypes(m *mpw) {
+// listPasswordTypes prints the valid password types, verbose adds their length, character set and entropy
+func listPasswordTypes(m *mpw, verbose bool) {
 	fmt.Println("=Valid Password Types=")
-	fmt.Println(strings.Join(m.GetPasswordTypes(), "\n"))
+	if !verbose {
+		fmt.Println(strings.Join(m.GetPasswordTypes(), "\n"))
+		return
+	}
+
+	// the word format is configurable
+	m.handleConfigMerging()
+	wf := crypto.NewWordFormat(m.Config.Words)
+
+	names := m.GetPasswordTypes()
+	aliases := map[string][]string{}
+	for _, name := range names {
+		if canonical := crypto.PasswordTypeName(name); canonical != name {
+			aliases[canonical] = append(aliases[canonical], name)
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tLENGTH\tTEMPLATES\tSPACE (bits)\tENTROPY (bits)\tCHARSET")
+	for _, name := range names {
+		if len(aliases[name]) == 0 && crypto.PasswordTypeName(name) != name {
+			continue
+		}
+		ts, err := crypto.PasswordTypeStats(name, wf)
+		if err != nil {
+			// e.g. --words being out of range for one of the word lists
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t%s\n", name, err)
+			continue
+		}
+
+		length := strconv.Itoa(ts.MinLength)
+		if ts.MaxLength != ts.MinLength {
+			length += "-" + strconv.Itoa(ts.MaxLength)
+		}
+		if len(aliases[name]) > 0 {
+			name += " (" + strings.Join(aliases[name], ",") + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%.1f\t%d %q\n",
+			name, length, ts.Templates, ts.SearchSpaceBits(), ts.Entropy, len(ts.Charset), charsetRanges(ts.Charset))
+	}
+	tw.Flush()
+
+	fmt.Println("\nTEMPLATES is the number of words for the word list types.")
+	fmt.Println("ENTROPY accounts for the modulo bias of the site key bytes selecting the template and characters.")
+}
+
+// charsetRanges abbreviates the runs of digits and letters of the sorted charset, e.g. "0-9A-Za-z"
+func charsetRanges(charset string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(charset); {
+		j := i
+		for j+1 < len(charset) && charset[j+1] == charset[j]+1 && isAlnum(charset[j+1]) && isAlnum(charset[i]) {
+			j++
+		}
+		if j-i >= 2 {
+			b.WriteByte(charset[i])
+			b.WriteByte('-')
+			b.WriteByte(charset[j])
+		} else {
+			b.WriteString(charset[i : j+1])
+		}
+		i = j + 1
+	}
+
+	return b.String()
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
 }
 
 func showVersion() {
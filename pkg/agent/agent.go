@@ -0,0 +1,307 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/debug"
+)
+
+var dbg = debug.NewDebug().Dbg
+
+// expireInterval is how often an idle agent checks for an expired master key
+const expireInterval = time.Second
+
+// Agent holds a MasterKey in locked memory and answers site password requests.
+//
+// Once locked, either explicitly or by Lifetime/IdleTimeout expiring, the MasterKey
+// is destroyed and the agent refuses requests until unlocked with the same master password.
+type Agent struct {
+	Lifetime    time.Duration // lock after this long since unlocking, 0 disables
+	IdleTimeout time.Duration // lock after this long without a site password request, 0 disables
+
+	mu                 sync.Mutex
+	fullname           string
+	masterPasswordSeed string
+	algorithmVersion   uint32
+	keyID              string
+	mk                 *crypto.MasterKey
+	unlocked           time.Time
+	lastUsed           time.Time
+	listener           net.Listener
+	done               chan struct{}
+	now                func() time.Time
+	newMasterKey       func(mpwseed, fullname, password string, algorithmVersion uint32) (*crypto.MasterKey, error)
+}
+
+// New returns a locked Agent for the given identity, keyID may be "" to accept the first Unlock()
+func New(mpwseed, fullname string, algorithmVersion uint32, keyID string) *Agent {
+	return &Agent{
+		fullname:           fullname,
+		masterPasswordSeed: mpwseed,
+		algorithmVersion:   algorithmVersion,
+		keyID:              keyID,
+		done:               make(chan struct{}),
+		now:                time.Now,
+		newMasterKey:       crypto.NewMasterKey,
+	}
+}
+
+// Unlock derives and locks the MasterKey into memory.
+//
+//   The master key ID is verified, so a later Unlock() must use the same master password.
+func (a *Agent) Unlock(password string) error {
+	// scrypt runs without holding a.mu
+	mk, err := a.newMasterKey(a.masterPasswordSeed, a.fullname, password, a.algorithmVersion)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// verified under a.mu, so concurrent Unlock()s can't install differing identities
+	if err = mk.VerifyID(a.keyID); err != nil {
+		mk.Destroy()
+		return err
+	}
+	if err = mk.Mlock(); err != nil {
+		log.Printf("[WARNING] Unable to lock the master key into memory: %s", err)
+	}
+
+	if a.mk != nil {
+		a.mk.Destroy()
+	}
+	a.mk = mk
+	a.keyID = mk.ID()
+	a.unlocked = a.now()
+	a.lastUsed = a.unlocked
+
+	return nil
+}
+
+// Lock destroys the MasterKey
+func (a *Agent) Lock() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.lock()
+}
+
+// lock destroys the MasterKey, a.mu is expected to be held
+func (a *Agent) lock() {
+	if a.mk != nil {
+		a.mk.Destroy()
+		a.mk = nil
+	}
+}
+
+// Status returns the agent's state
+func (a *Agent) Status() *Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expire()
+
+	s := &Status{
+		PID:              os.Getpid(),
+		Fullname:         a.fullname,
+		AlgorithmVersion: a.algorithmVersion,
+		KeyID:            a.keyID,
+		Locked:           a.mk == nil,
+	}
+	if !s.Locked {
+		s.Expires = a.expires()
+	}
+
+	return s
+}
+
+// SitePassword derives the site password for req, see Request for the identity matching rules
+func (a *Agent) SitePassword(req *Request) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.expire()
+	if a.mk == nil {
+		return "", ErrLocked
+	}
+
+	if (req.Fullname != "" && req.Fullname != a.fullname) ||
+		(req.MasterPasswordSeed != "" && req.MasterPasswordSeed != a.masterPasswordSeed) ||
+		(req.AlgorithmVersion != nil && *req.AlgorithmVersion != a.algorithmVersion) {
+		return "", ErrIdentityMismatch
+	}
+	if err := a.mk.VerifyID(req.KeyID); err != nil {
+		return "", err
+	}
+
+	a.lastUsed = a.now()
+
+	return a.mk.SitePasswordEX(req.PasswordType, req.Options, req.Site, req.Counter, req.PasswordPurpose, req.KeyContext)
+}
+
+// expires returns when the MasterKey will be locked, zero if never; a.mu is expected to be held
+func (a *Agent) expires() time.Time {
+	var t time.Time
+	if a.Lifetime > 0 {
+		t = a.unlocked.Add(a.Lifetime)
+	}
+	if a.IdleTimeout > 0 {
+		if idle := a.lastUsed.Add(a.IdleTimeout); t.IsZero() || idle.Before(t) {
+			t = idle
+		}
+	}
+
+	return t
+}
+
+// expire locks the agent once Lifetime or IdleTimeout has elapsed; a.mu is expected to be held
+func (a *Agent) expire() {
+	if a.mk == nil {
+		return
+	}
+	if t := a.expires(); !t.IsZero() && !a.now().Before(t) {
+		dbg("master key expired, locking")
+		a.lock()
+	}
+}
+
+// Serve answers requests on l until Close() is called
+func (a *Agent) Serve(l net.Listener) error {
+	a.mu.Lock()
+	a.listener = l
+	a.mu.Unlock()
+
+	go a.expireLoop()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			select {
+			case <-a.done:
+				return nil
+			default:
+			}
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return err
+		}
+		go a.handleConn(conn)
+	}
+}
+
+// Close stops serving and destroys the MasterKey
+func (a *Agent) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	default:
+	}
+	close(a.done)
+	a.lock()
+	if a.listener != nil {
+		return a.listener.Close()
+	}
+
+	return nil
+}
+
+// expireLoop promptly destroys an expired MasterKey, rather than waiting for the next request
+func (a *Agent) expireLoop() {
+	ticker := time.NewTicker(expireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
+			return
+		case <-ticker.C:
+			a.mu.Lock()
+			a.expire()
+			a.mu.Unlock()
+		}
+	}
+}
+
+// handleConn answers the connection's requests, one JSON line each
+func (a *Agent) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		var resp *Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = &Response{Error: err.Error()}
+		} else {
+			resp = a.handle(&req)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+		if req.Op == OpStop {
+			a.Close()
+			return
+		}
+	}
+}
+
+// handle dispatches a single request
+func (a *Agent) handle(req *Request) *Response {
+	var err error
+	resp := &Response{}
+
+	switch req.Op {
+	case OpLock:
+		a.Lock()
+	case OpPassword:
+		if resp.Password, err = a.SitePassword(req); err == nil {
+			s := a.Status()
+			resp.Fullname, resp.KeyID = s.Fullname, s.KeyID
+		}
+	case OpStatus:
+		resp.Status = a.Status()
+	case OpStop:
+	case OpUnlock:
+		err = a.Unlock(req.Password)
+	default:
+		err = ErrOpInvalid
+	}
+	if err != nil {
+		resp = &Response{Error: err.Error()}
+	}
+
+	return resp
+}
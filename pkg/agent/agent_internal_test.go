@@ -0,0 +1,309 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/common"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rlmFullname = "Robert Lee Mitchell"
+	rlmPassword = "banana colored duckling"
+	rlmKeyID    = "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302"
+)
+
+var rlmSite = &Request{
+	Site:            "masterpasswordapp.com",
+	PasswordType:    "long",
+	PasswordPurpose: "auth",
+	Counter:         1,
+}
+
+// serve starts an unlocked agent on a temporary socket
+func serve(t *testing.T) (*Agent, *Client, func()) {
+	dir, path, err := SocketPath()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	l, err := Listen(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	a := New(common.DefaultMasterPasswordSeed, rlmFullname, 3, "")
+	if !assert.NoError(t, a.Unlock(rlmPassword)) {
+		t.FailNow()
+	}
+	go a.Serve(l)
+
+	return a, NewClient(path), func() {
+		a.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestAgentSocket(t *testing.T) {
+	_, c, cleanup := serve(t)
+	defer cleanup()
+
+	fi, err := os.Stat(c.path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+		assert.True(t, fi.Mode()&os.ModeSocket != 0)
+	}
+	fi, err = os.Stat(filepath.Dir(c.path))
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+	}
+}
+
+func TestAgentSitePassword(t *testing.T) {
+	_, c, cleanup := serve(t)
+	defer cleanup()
+
+	resp, err := c.SitePassword(rlmSite)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Jejr5[RepuSosp", resp.Password)
+		assert.Equal(t, rlmFullname, resp.Fullname)
+		assert.Equal(t, rlmKeyID, resp.KeyID)
+	}
+
+	var v3 uint32 = 3
+	req := *rlmSite
+	req.Fullname = rlmFullname
+	req.MasterPasswordSeed = common.DefaultMasterPasswordSeed
+	req.AlgorithmVersion = &v3
+	req.KeyID = rlmKeyID
+	_, err = c.SitePassword(&req)
+	assert.NoError(t, err)
+
+	req.KeyID = "00" + rlmKeyID[2:]
+	_, err = c.SitePassword(&req)
+	assert.Equal(t, crypto.ErrMasterKeyIDMismatch, err)
+
+	var v2 uint32 = 2
+	req = *rlmSite
+	req.AlgorithmVersion = &v2
+	_, err = c.SitePassword(&req)
+	assert.Equal(t, ErrIdentityMismatch, err)
+
+	req = *rlmSite
+	req.Fullname = "Robert Lee"
+	_, err = c.SitePassword(&req)
+	assert.Equal(t, ErrIdentityMismatch, err)
+
+	req = *rlmSite
+	req.PasswordType = "bogus"
+	_, err = c.SitePassword(&req)
+	assert.Equal(t, crypto.ErrPasswordTypeInvalid.Error(), err.Error())
+}
+
+// TestAgentCustomPasswordType tests a password type registered before serving, as from [types."name"]
+func TestAgentCustomPasswordType(t *testing.T) {
+	err := crypto.RegisterPasswordType("agent-custom", []string{"CvcvnnCvcvyy", "yyCvcvCvcvnn"}, map[string]string{"y": "@&%?"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	mk, err := crypto.NewMasterKey(common.DefaultMasterPasswordSeed, rlmFullname, rlmPassword, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer mk.Destroy()
+	expect, err := mk.SitePassword("agent-custom", rlmSite.Site, rlmSite.Counter, rlmSite.PasswordPurpose, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	_, c, cleanup := serve(t)
+	defer cleanup()
+
+	req := *rlmSite
+	req.PasswordType = "agent-custom"
+	resp, err := c.SitePassword(&req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, expect, resp.Password)
+	}
+}
+
+func TestAgentLockUnlock(t *testing.T) {
+	_, c, cleanup := serve(t)
+	defer cleanup()
+
+	assert.NoError(t, c.Lock())
+	_, err := c.SitePassword(rlmSite)
+	assert.Equal(t, ErrLocked, err)
+
+	s, err := c.Status()
+	if assert.NoError(t, err) {
+		assert.True(t, s.Locked)
+		assert.Equal(t, rlmFullname, s.Fullname)
+		assert.Equal(t, rlmKeyID, s.KeyID)
+		assert.Equal(t, os.Getpid(), s.PID)
+	}
+
+	// unlocking must reproduce the same master key
+	assert.Equal(t, crypto.ErrMasterKeyIDMismatch, c.Unlock("banana colored ducklinq"))
+	assert.NoError(t, c.Unlock(rlmPassword))
+	resp, err := c.SitePassword(rlmSite)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Jejr5[RepuSosp", resp.Password)
+	}
+
+	_, err = c.do(&Request{Op: "bogus"})
+	assert.Equal(t, ErrOpInvalid, err)
+}
+
+func TestAgentTimeouts(t *testing.T) {
+	a, c, cleanup := serve(t)
+	defer cleanup()
+
+	now := time.Now()
+	a.mu.Lock()
+	a.Lifetime = time.Hour
+	a.IdleTimeout = 10 * time.Minute
+	a.unlocked = now
+	a.lastUsed = now
+	a.now = func() time.Time { return now }
+	a.mu.Unlock()
+	advance := func(d time.Duration) {
+		a.mu.Lock()
+		now = now.Add(d)
+		a.mu.Unlock()
+	}
+
+	s, err := c.Status()
+	if assert.NoError(t, err) {
+		assert.False(t, s.Locked)
+		assert.True(t, s.Expires.Equal(now.Add(10*time.Minute)))
+	}
+
+	// requests keep the idle timeout at bay
+	for i := 0; i < 6; i++ {
+		advance(9 * time.Minute)
+		_, err = c.SitePassword(rlmSite)
+		if !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	// but not the lifetime
+	advance(9 * time.Minute)
+	_, err = c.SitePassword(rlmSite)
+	assert.Equal(t, ErrLocked, err)
+
+	// idle timeout
+	assert.NoError(t, a.Unlock(rlmPassword))
+	advance(10 * time.Minute)
+	_, err = c.SitePassword(rlmSite)
+	assert.Equal(t, ErrLocked, err)
+}
+
+func TestAgentStop(t *testing.T) {
+	dir, path, err := SocketPath()
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	l, err := Listen(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	a := New(common.DefaultMasterPasswordSeed, rlmFullname, 3, rlmKeyID)
+	assert.Equal(t, crypto.ErrMasterKeyIDMismatch, a.Unlock("banana colored ducklinq"))
+
+	served := make(chan error)
+	go func() { served <- a.Serve(l) }()
+
+	c := NewClient(path)
+	assert.NoError(t, c.Stop())
+	select {
+	case err = <-served:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("agent did not stop")
+	}
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// TestAgentUnlockConcurrent tests that only one identity is accepted by concurrent Unlock()s, run with -race
+func TestAgentUnlockConcurrent(t *testing.T) {
+	a := New(common.DefaultMasterPasswordSeed, rlmFullname, 3, "")
+	defer a.Close()
+
+	passwords := []string{rlmPassword, "banana colored ducklinq", "banana colored duckline"}
+	errs := make([]error, len(passwords))
+
+	// all the master keys are derived before any of them is installed
+	var derived sync.WaitGroup
+	derived.Add(len(passwords))
+	a.newMasterKey = func(mpwseed, fullname, password string, algorithmVersion uint32) (*crypto.MasterKey, error) {
+		mk, err := crypto.NewMasterKey(mpwseed, fullname, password, algorithmVersion)
+		derived.Done()
+		derived.Wait()
+		return mk, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range passwords {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = a.Unlock(passwords[i])
+		}(i)
+	}
+	wg.Wait()
+
+	unlocked := -1
+	for i, err := range errs {
+		if err == nil {
+			assert.Equal(t, -1, unlocked, "more than one identity was accepted")
+			unlocked = i
+			continue
+		}
+		assert.Equal(t, crypto.ErrMasterKeyIDMismatch, err)
+	}
+	if !assert.NotEqual(t, -1, unlocked) {
+		return
+	}
+
+	// the accepted identity is kept
+	a.newMasterKey = crypto.NewMasterKey
+	keyID := a.Status().KeyID
+	for i, password := range passwords {
+		if i == unlocked {
+			assert.NoError(t, a.Unlock(password))
+		} else {
+			assert.Equal(t, crypto.ErrMasterKeyIDMismatch, a.Unlock(password))
+		}
+	}
+	assert.Equal(t, keyID, a.Status().KeyID)
+}
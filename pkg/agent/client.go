@@ -0,0 +1,142 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// client timeouts, unlocking includes an scrypt derivation
+const (
+	dialTimeout    = 2 * time.Second
+	requestTimeout = 30 * time.Second
+)
+
+// Listen creates the agent's Unix socket, accessible only by the current user.
+//
+//   NOTE: the socket is created within a private (0700) directory, see SocketPath()
+func Listen(path string) (net.Listener, error) {
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// SocketPath returns a socket path within a freshly created private directory, which the caller should remove
+func SocketPath() (dir, path string, err error) {
+	dir, err = ioutil.TempDir("", "gompw-")
+	if err != nil {
+		return "", "", err
+	}
+
+	return dir, filepath.Join(dir, fmt.Sprintf("agent.%d", os.Getpid())), nil
+}
+
+// Client talks to an agent over its Unix socket
+type Client struct {
+	path string
+}
+
+// NewClient returns a Client for the agent listening on path
+func NewClient(path string) *Client {
+	return &Client{path: path}
+}
+
+// NewClientFromEnv returns a Client for the agent advertised via GOMPW_AUTH_SOCK, nil if unset
+func NewClientFromEnv() *Client {
+	path := os.Getenv(EnvAuthSock)
+	if path == "" {
+		return nil
+	}
+
+	return NewClient(path)
+}
+
+// Lock locks the agent, destroying its master key
+func (c *Client) Lock() error {
+	_, err := c.do(&Request{Op: OpLock})
+	return err
+}
+
+// SitePassword requests the site password for req, the response includes the agent's fullname and key ID
+func (c *Client) SitePassword(req *Request) (*Response, error) {
+	r := *req
+	r.Op = OpPassword
+
+	return c.do(&r)
+}
+
+// Status returns the agent's state
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.do(&Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Status, nil
+}
+
+// Stop terminates the agent
+func (c *Client) Stop() error {
+	_, err := c.do(&Request{Op: OpStop})
+	return err
+}
+
+// Unlock unlocks the agent, password must match the agent's master key ID
+func (c *Client) Unlock(password string) error {
+	_, err := c.do(&Request{Op: OpUnlock, Password: password})
+	return err
+}
+
+// do sends a single request and returns the agent's response
+func (c *Client) do(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.path, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		return nil, err
+	}
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	var resp Response
+	if err = json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, resp.err()
+}
@@ -0,0 +1,110 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package agent
+
+import (
+	"errors"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+)
+
+// EnvAuthSock is the environment variable advertising the agent's socket, in the spirit of SSH_AUTH_SOCK
+const EnvAuthSock = "GOMPW_AUTH_SOCK"
+
+// Agent protocol operations
+const (
+	OpLock     = "lock"
+	OpPassword = "password"
+	OpStatus   = "status"
+	OpStop     = "stop"
+	OpUnlock   = "unlock"
+)
+
+// Agent exported errors
+var (
+	ErrIdentityMismatch = errors.New("Agent holds the master key of a different identity")
+	ErrLocked           = errors.New("Agent is locked")
+	ErrOpInvalid        = errors.New("Invalid agent operation")
+)
+
+// errs maps the wire errors back to their exported counterparts
+var errs = map[string]error{
+	ErrIdentityMismatch.Error(): ErrIdentityMismatch,
+	ErrLocked.Error():           ErrLocked,
+	ErrOpInvalid.Error():        ErrOpInvalid,
+
+	crypto.ErrMasterKeyIDMismatch.Error(): crypto.ErrMasterKeyIDMismatch,
+}
+
+// Request is a single agent request, sent as a line of JSON.
+//
+//   Fullname, MasterPasswordSeed, AlgorithmVersion and KeyID are optional for OpPassword,
+//   when given they must match the agent's, otherwise ErrIdentityMismatch is returned.
+type Request struct {
+	Op string `json:"op"`
+
+	// OpUnlock
+	Password string `json:"password,omitempty"`
+
+	// OpPassword
+	Fullname           string                  `json:"fullname,omitempty"`
+	MasterPasswordSeed string                  `json:"masterPasswordSeed,omitempty"`
+	AlgorithmVersion   *uint32                 `json:"algorithmVersion,omitempty"`
+	KeyID              string                  `json:"keyID,omitempty"`
+	Site               string                  `json:"site,omitempty"`
+	PasswordType       string                  `json:"passwordType,omitempty"`
+	PasswordPurpose    string                  `json:"passwordPurpose,omitempty"`
+	KeyContext         string                  `json:"keyContext,omitempty"`
+	Options            *crypto.PasswordOptions `json:"options,omitempty"`
+	Counter            uint32                  `json:"counter,omitempty"`
+}
+
+// Response is the agent's reply to a Request, sent as a line of JSON
+type Response struct {
+	Error    string  `json:"error,omitempty"`
+	Fullname string  `json:"fullname,omitempty"`
+	KeyID    string  `json:"keyID,omitempty"`
+	Password string  `json:"password,omitempty"`
+	Status   *Status `json:"status,omitempty"`
+}
+
+// Status describes the agent's state
+type Status struct {
+	PID              int       `json:"pid"`
+	Fullname         string    `json:"fullname"`
+	AlgorithmVersion uint32    `json:"algorithmVersion"`
+	KeyID            string    `json:"keyID"`
+	Locked           bool      `json:"locked"`
+	Expires          time.Time `json:"expires,omitempty"` // zero if the agent has no lifetime/idle timeout
+}
+
+// err converts the Response's wire error back into an error
+func (r *Response) err() error {
+	if r.Error == "" {
+		return nil
+	}
+	if err, ok := errs[r.Error]; ok {
+		return err
+	}
+
+	return errors.New(r.Error)
+}
@@ -0,0 +1,81 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package askpass
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ErrPromptUnsupported is returned for prompts not asking for a password, e.g. ssh's host key confirmation
+var ErrPromptUnsupported = errors.New("Unsupported askpass prompt")
+
+// Prompt is a parsed askpass prompt
+type Prompt struct {
+	User string // "" if not part of the prompt
+	Host string // the ssh host, the key's basename for passphrases, the local hostname for sudo
+}
+
+// prompts maps the supported prompts to their parser
+var prompts = []struct {
+	re    *regexp.Regexp
+	parse func(m []string, hostname string) *Prompt
+}{
+	// ssh: user@host's password:
+	{regexp.MustCompile(`^(\S+)@(\S+)'s password:\s*$`), func(m []string, _ string) *Prompt {
+		return &Prompt{User: m[1], Host: m[2]}
+	}},
+	// ssh (keyboard-interactive): (user@host) Password:
+	{regexp.MustCompile(`^\((\S+)@(\S+)\) [Pp]assword:\s*$`), func(m []string, _ string) *Prompt {
+		return &Prompt{User: m[1], Host: m[2]}
+	}},
+	// ssh: Enter passphrase for key '/home/user/.ssh/id_ed25519':
+	{regexp.MustCompile(`^Enter passphrase for (?:key )?'?(.+?)'?:\s*$`), func(m []string, _ string) *Prompt {
+		return &Prompt{Host: filepath.Base(m[1])}
+	}},
+	// sudo: [sudo] password for user:
+	{regexp.MustCompile(`^\[sudo\] password for (\S+):\s*$`), func(m []string, hostname string) *Prompt {
+		return &Prompt{User: m[1], Host: hostname}
+	}},
+}
+
+// Parse parses the prompt given to an askpass program (argv[1]), hostname is used for sudo's prompt
+func Parse(prompt, hostname string) (*Prompt, error) {
+	prompt = strings.TrimSpace(prompt)
+	for _, p := range prompts {
+		if m := p.re.FindStringSubmatch(prompt); m != nil {
+			return p.parse(m, hostname), nil
+		}
+	}
+
+	return nil, ErrPromptUnsupported
+}
+
+// Sites returns the candidate site names, most specific first: user@host, host
+func (p *Prompt) Sites() []string {
+	if p.User == "" {
+		return []string{p.Host}
+	}
+
+	return []string{p.User + "@" + p.Host, p.Host}
+}
@@ -0,0 +1,60 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package askpass_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/askpass"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	expectations := []struct {
+		prompt string
+		expect *askpass.Prompt
+		sites  []string
+	}{
+		{"rlm@example.com's password: ", &askpass.Prompt{User: "rlm", Host: "example.com"}, []string{"rlm@example.com", "example.com"}},
+		{"(rlm@10.0.0.1) Password: ", &askpass.Prompt{User: "rlm", Host: "10.0.0.1"}, []string{"rlm@10.0.0.1", "10.0.0.1"}},
+		{"Enter passphrase for key '/home/rlm/.ssh/id_ed25519': ", &askpass.Prompt{Host: "id_ed25519"}, []string{"id_ed25519"}},
+		{"Enter passphrase for /home/rlm/.ssh/id_rsa:", &askpass.Prompt{Host: "id_rsa"}, []string{"id_rsa"}},
+		{"[sudo] password for rlm: ", &askpass.Prompt{User: "rlm", Host: "localhost"}, []string{"rlm@localhost", "localhost"}},
+	}
+
+	for _, e := range expectations {
+		p, err := askpass.Parse(e.prompt, "localhost")
+		if !assert.NoError(t, err, e.prompt) {
+			continue
+		}
+		assert.Equal(t, e.expect, p)
+		assert.Equal(t, e.sites, p.Sites())
+	}
+
+	for _, prompt := range []string{
+		"Are you sure you want to continue connecting (yes/no/[fingerprint])? ",
+		"Allow use of key /home/rlm/.ssh/id_ed25519?",
+		"",
+	} {
+		_, err := askpass.Parse(prompt, "localhost")
+		assert.Equal(t, askpass.ErrPromptUnsupported, err, prompt)
+	}
+}
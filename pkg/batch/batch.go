@@ -0,0 +1,182 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Batch exported errors
+var (
+	ErrCounterInvalid = errors.New("Counter is invalid")
+	ErrFieldCount     = errors.New("Expected 1 to 5 fields: site,counter,type,purpose,context")
+)
+
+// Format of a batch line, results are written in the same format as their line
+type Format int
+
+// Batch line formats
+const (
+	FormatCSV Format = iota
+	FormatJSON
+)
+
+// csvHeader is optional, and skipped when it is the first line
+var csvHeader = []string{"site", "counter", "type", "purpose", "context"}
+
+// Spec is a single site specification, empty fields are filled in from the configuration
+type Spec struct {
+	Site            string `json:"site"`
+	Counter         uint32 `json:"counter,omitempty"`
+	PasswordType    string `json:"type,omitempty"`
+	PasswordPurpose string `json:"purpose,omitempty"`
+	KeyContext      string `json:"context,omitempty"`
+}
+
+// Result is the outcome of a single batch line
+type Result struct {
+	Line int `json:"line"`
+	Spec
+	Password string `json:"password,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DeriveFunc returns the password for spec, filling in spec's empty fields with the values used
+type DeriveFunc func(spec *Spec) (string, error)
+
+// Run derives the password of each line read from r, writing a Result per line to w.
+//
+//   Lines are either JSON objects, or CSV: site[,counter[,type[,purpose[,context]]]]
+//   Blank lines and #comments are skipped.
+//
+// The number of failed lines is returned, err is only set upon an I/O error.
+func Run(r io.Reader, w io.Writer, derive DeriveFunc) (failed int, err error) {
+	scanner := bufio.NewScanner(r)
+	first := true
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		format := FormatCSV
+		if strings.HasPrefix(line, "{") {
+			format = FormatJSON
+		}
+
+		spec, err := parse(line, format, first)
+		first = false
+		if err == nil && spec == nil {
+			continue
+		}
+
+		res := &Result{Line: n}
+		if err == nil {
+			res.Spec = *spec
+			res.Password, err = derive(&res.Spec)
+		}
+		if err != nil {
+			res.Password = ""
+			res.Error = err.Error()
+			failed++
+		}
+
+		if err = write(w, res, format); err != nil {
+			return failed, err
+		}
+	}
+
+	return failed, scanner.Err()
+}
+
+// parse returns the line's Spec, or nil for a CSV header line when first is set
+func parse(line string, format Format, first bool) (*Spec, error) {
+	if format == FormatJSON {
+		var spec Spec
+		if err := json.Unmarshal([]byte(line), &spec); err != nil {
+			return nil, err
+		}
+		return &spec, nil
+	}
+
+	fields, err := csv.NewReader(strings.NewReader(line)).Read()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) > len(csvHeader) {
+		return nil, ErrFieldCount
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	if first && strings.EqualFold(fields[0], csvHeader[0]) {
+		return nil, nil
+	}
+
+	// pad the optional fields
+	fields = append(fields, make([]string, len(csvHeader)-len(fields))...)
+	spec := &Spec{
+		Site:            fields[0],
+		PasswordType:    fields[2],
+		PasswordPurpose: fields[3],
+		KeyContext:      fields[4],
+	}
+	if fields[1] != "" {
+		counter, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, ErrCounterInvalid
+		}
+		spec.Counter = uint32(counter)
+	}
+
+	return spec, nil
+}
+
+// write outputs res as a single line in the given format
+func write(w io.Writer, res *Result, format Format) error {
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(res)
+	}
+
+	var counter string
+	if res.Counter != 0 {
+		counter = strconv.FormatUint(uint64(res.Counter), 10)
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write([]string{fmt.Sprint(res.Line), res.Site, counter, res.PasswordType, res.PasswordPurpose, res.KeyContext, res.Password, res.Error})
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+
+	return err
+}
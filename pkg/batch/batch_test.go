@@ -0,0 +1,100 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package batch_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/batch"
+	"github.com/stretchr/testify/assert"
+)
+
+// derive fills in the defaults and returns a reversible stand-in for the password
+func derive(spec *batch.Spec) (string, error) {
+	if spec.Site == "" {
+		return "", errors.New("Site is invalid")
+	}
+	if spec.Counter == 0 {
+		spec.Counter = 1
+	}
+	if spec.PasswordType == "" {
+		spec.PasswordType = "long"
+	}
+	if spec.PasswordPurpose == "" {
+		spec.PasswordPurpose = "auth"
+	}
+
+	return strings.ToUpper(spec.Site), nil
+}
+
+func TestRunCSV(t *testing.T) {
+	input := `site,counter,type,purpose,context
+# comment
+example.com
+
+example.org, 2, maximum
+"a,b.com",,,rec,"mother's ""maiden"" name"
+,3
+bogus.com,x
+too.com,1,long,auth,ctx,extra
+`
+	expected := `1,EXAMPLE.COM
+3,example.com,1,long,auth,,EXAMPLE.COM,
+5,example.org,2,maximum,auth,,EXAMPLE.ORG,
+6,"a,b.com",1,long,rec,"mother's ""maiden"" name","A,B.COM",
+7,,3,,,,,Site is invalid
+8,,,,,,,Counter is invalid
+9,,,,,,,"Expected 1 to 5 fields: site,counter,type,purpose,context"
+`
+	var out bytes.Buffer
+	failed, err := batch.Run(strings.NewReader(input), &out, derive)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, failed)
+	// the header is only skipped as the first line
+	assert.Equal(t, strings.SplitN(expected, "\n", 2)[1], out.String())
+
+	out.Reset()
+	failed, err = batch.Run(strings.NewReader("example.com\nsite\n"), &out, derive)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, "1,example.com,1,long,auth,,EXAMPLE.COM,\n2,site,1,long,auth,,SITE,\n", out.String())
+}
+
+func TestRunJSON(t *testing.T) {
+	input := `{"site": "example.com", "counter": 2, "purpose": "rec", "context": "q"}
+{"site": ""}
+{"site": 
+example.org
+`
+	expected := `{"line":1,"site":"example.com","counter":2,"type":"long","purpose":"rec","context":"q","password":"EXAMPLE.COM"}
+{"line":2,"site":"","error":"Site is invalid"}
+{"line":3,"site":"","error":"unexpected end of JSON input"}
+4,example.org,1,long,auth,,EXAMPLE.ORG,
+`
+	var out bytes.Buffer
+	failed, err := batch.Run(strings.NewReader(input), &out, derive)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, failed)
+	assert.Equal(t, expected, out.String())
+}
@@ -0,0 +1,249 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// Package breach looks up passwords in an offline Pwned Passwords corpus, so they never leave the host.
+//
+//   Two layouts of the corpus are supported:
+//     range directory: one file per 5 hex digit SHA-1 prefix, named "21BD1" or "21BD1.txt", holding the
+//                      "SUFFIX:COUNT" lines of https://api.pwnedpasswords.com/range/21BD1 (k-anonymity)
+//     hash file:       "HASH:COUNT" lines sorted by hash, e.g. pwned-passwords-sha1-ordered-by-hash-v8.txt
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PrefixLength is the number of hex digits of the SHA-1 naming a range file
+const PrefixLength = 5
+
+// breach exported errors
+var (
+	ErrCorpusEmpty   = errors.New("Breach corpus must be set")
+	ErrCorpusInvalid = errors.New("Breach corpus must be a range directory or a sorted hash file")
+	ErrLineInvalid   = errors.New("Breach corpus line is invalid")
+)
+
+// Corpus reports how often a password occurs within a breach corpus
+type Corpus interface {
+	// Count returns the number of breaches of password, 0 if it was not found
+	Count(password string) (int64, error)
+	Close() error
+}
+
+// Open returns the Corpus at path, a range directory or a sorted hash file
+func Open(path string) (Corpus, error) {
+	if path == "" {
+		return nil, ErrCorpusEmpty
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case fi.IsDir():
+		return &RangeDir{Dir: path}, nil
+	case fi.Mode().IsRegular():
+		return OpenHashFile(path)
+	}
+
+	return nil, ErrCorpusInvalid
+}
+
+// Hash returns the uppercase hex SHA-1 of password, as used by the corpus
+func Hash(password string) string {
+	sum := sha1.Sum([]byte(password))
+
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// RangeDir is a directory of range files.
+//
+//   A missing range file counts as not breached, so a partial corpus may be used.
+type RangeDir struct {
+	Dir string
+}
+
+// Count implements Corpus
+func (rd *RangeDir) Count(password string) (int64, error) {
+	return rd.countHash(Hash(password))
+}
+
+func (rd *RangeDir) countHash(hash string) (int64, error) {
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+
+	var f *os.File
+	var err error
+	for _, name := range []string{prefix + ".txt", prefix, strings.ToLower(prefix) + ".txt", strings.ToLower(prefix)} {
+		if f, err = os.Open(filepath.Join(rd.Dir, name)); err == nil || !os.IsNotExist(err) {
+			break
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer f.Close()
+
+	// range files are small (~800 lines), and not necessarily sorted
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		s, count, err := parseLine(scanner.Text())
+		if err != nil {
+			return 0, err
+		}
+		if s == suffix {
+			return count, nil
+		}
+	}
+
+	return 0, scanner.Err()
+}
+
+// Close implements Corpus
+func (rd *RangeDir) Close() error {
+	return nil
+}
+
+// HashFile is a file of hashes sorted in ascending order, which is binary searched
+type HashFile struct {
+	f    *os.File
+	size int64
+}
+
+// OpenHashFile opens the sorted hash file at path
+func OpenHashFile(path string) (*HashFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &HashFile{f: f, size: fi.Size()}, nil
+}
+
+// Count implements Corpus
+func (hf *HashFile) Count(password string) (int64, error) {
+	return hf.countHash(Hash(password))
+}
+
+func (hf *HashFile) countHash(hash string) (int64, error) {
+	// find the first line, starting at or after lo, not sorting before hash
+	lo, hi := int64(0), hf.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		line, err := hf.lineFrom(mid)
+		if err == io.EOF {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		h, _, err := parseLine(line)
+		if err != nil {
+			return 0, err
+		}
+		if h < hash {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	line, err := hf.lineFrom(lo)
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	h, count, err := parseLine(line)
+	if err != nil || h != hash {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Close implements Corpus
+func (hf *HashFile) Close() error {
+	return hf.f.Close()
+}
+
+// lineFrom returns the first complete line starting at or after off, io.EOF if there is none
+func (hf *HashFile) lineFrom(off int64) (string, error) {
+	start := off
+	if off > 0 {
+		// include the preceding byte, in case off is already at the start of a line
+		start--
+	}
+	r := bufio.NewReader(io.NewSectionReader(hf.f, start, hf.size-start))
+	if off > 0 {
+		if _, err := r.ReadString('\n'); err != nil {
+			if err == io.EOF {
+				return "", io.EOF
+			}
+			return "", err
+		}
+	}
+
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	return line, err
+}
+
+// parseLine returns the uppercase hash (or suffix) and count of a "HASH:COUNT" line, the count defaulting to 1
+func parseLine(line string) (string, int64, error) {
+	line = strings.TrimSpace(line)
+	hash, count := line, int64(1)
+	if i := strings.IndexByte(line, ':'); i >= 0 {
+		var err error
+		hash = line[:i]
+		if count, err = strconv.ParseInt(line[i+1:], 10, 64); err != nil {
+			return "", 0, ErrLineInvalid
+		}
+	}
+	if hash == "" {
+		return "", 0, ErrLineInvalid
+	}
+
+	return strings.ToUpper(hash), count, nil
+}
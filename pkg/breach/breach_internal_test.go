@@ -0,0 +1,112 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package breach
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// every line of the hash file must be found by the binary search, including the first and last
+func TestHashFileCountHash(t *testing.T) {
+	hf, err := OpenHashFile("../../files/breach/pwned-passwords-sha1-ordered-by-hash.txt")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer hf.Close()
+
+	f, err := os.Open(hf.f.Name())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		hash, count, err := parseLine(scanner.Text())
+		if !assert.NoError(t, err) {
+			return
+		}
+		c, err := hf.countHash(hash)
+		assert.NoError(t, err, hash)
+		assert.Equal(t, count, c, hash)
+		n++
+	}
+	assert.Equal(t, 40, n)
+
+	for _, hash := range []string{"0000000000000000000000000000000000000000", "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF"} {
+		c, err := hf.countHash(hash)
+		assert.NoError(t, err, hash)
+		assert.Equal(t, int64(0), c, hash)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	expectations := []struct {
+		line  string
+		hash  string
+		count int64
+		err   error
+	}{
+		{"1e4c9b93f3f0682250b6cf8331b7ee68fd8:3\r\n", "1E4C9B93F3F0682250B6CF8331B7EE68FD8", 3, nil},
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", 1, nil},
+		{"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:x", "", 0, ErrLineInvalid},
+		{":3", "", 0, ErrLineInvalid},
+	}
+
+	for _, tv := range expectations {
+		hash, count, err := parseLine(tv.line)
+		assert.Equal(t, tv.err, err, tv.line)
+		assert.Equal(t, tv.hash, hash, tv.line)
+		assert.Equal(t, tv.count, count, tv.line)
+	}
+}
+
+func TestHashFileSingleLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "breach")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	fn := filepath.Join(dir, "hashes.txt")
+	if !assert.NoError(t, ioutil.WriteFile(fn, []byte(Hash("password")+":7"), 0600)) {
+		return
+	}
+	hf, err := OpenHashFile(fn)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer hf.Close()
+
+	count, err := hf.Count("password")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), count)
+	count, err = hf.Count("123456")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
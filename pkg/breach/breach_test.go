@@ -0,0 +1,87 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package breach_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/breach"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	fixtureRangeDir = "../../files/breach/range"
+	fixtureHashFile = "../../files/breach/pwned-passwords-sha1-ordered-by-hash.txt"
+)
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8", breach.Hash("password"))
+}
+
+func TestCount(t *testing.T) {
+	expectations := []struct {
+		password string
+		count    int64
+	}{
+		{"password", 9545824},
+		{"123456", 37359195},
+		{"qwerty", 10556095},
+		{"letmein", 655997},
+		// v3 long password of user/password/example.com
+		{"ZedaFaxcZaso9*", 2},
+		// prefix within the corpus, suffix not
+		{"Password", 0},
+		{"ZedaFaxcZaso9!", 0},
+		{"correct horse battery staple", 0},
+		{"", 0},
+	}
+
+	for _, path := range []string{fixtureRangeDir, fixtureHashFile} {
+		c, err := breach.Open(path)
+		if !assert.NoError(t, err, path) {
+			continue
+		}
+		for _, tv := range expectations {
+			count, err := c.Count(tv.password)
+			assert.NoError(t, err, "%s %q", path, tv.password)
+			assert.Equal(t, tv.count, count, "%s %q", path, tv.password)
+		}
+		assert.NoError(t, c.Close())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	_, err := breach.Open("")
+	assert.Equal(t, breach.ErrCorpusEmpty, err)
+
+	_, err = breach.Open("noexist")
+	assert.Error(t, err)
+
+	c, err := breach.Open(fixtureRangeDir)
+	if assert.NoError(t, err) {
+		assert.IsType(t, &breach.RangeDir{}, c)
+	}
+	c, err = breach.Open(fixtureHashFile)
+	if assert.NoError(t, err) {
+		assert.IsType(t, &breach.HashFile{}, c)
+		c.Close()
+	}
+}
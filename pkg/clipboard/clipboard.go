@@ -0,0 +1,144 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package clipboard
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Clipboard exported errors
+var (
+	ErrCommandEmpty     = errors.New("Clipboard copy command must be set")
+	ErrPasteUnsupported = errors.New("Clipboard does not support pasting")
+)
+
+// Clipboard is the system clipboard
+type Clipboard interface {
+	Copy(text string) error
+	Paste() (string, error) // ErrPasteUnsupported if the clipboard is write-only
+}
+
+// Command is a Clipboard backed by external helper commands, e.g. xclip, xsel, wl-copy or pbcopy
+type Command struct {
+	copy  []string
+	paste []string
+}
+
+// NewCommand returns a Command Clipboard, the commands are split on whitespace (no shell quoting).
+//
+//   copy receives the text on its stdin, paste is optional and should output the clipboard on its stdout
+func NewCommand(copy, paste string) (*Command, error) {
+	c := &Command{
+		copy:  strings.Fields(copy),
+		paste: strings.Fields(paste),
+	}
+	if len(c.copy) == 0 {
+		return nil, ErrCommandEmpty
+	}
+
+	return c, nil
+}
+
+// Copy pipes text into the copy command
+func (c *Command) Copy(text string) error {
+	cmd := exec.Command(c.copy[0], c.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// Paste returns the output of the paste command
+func (c *Command) Paste() (string, error) {
+	if len(c.paste) == 0 {
+		return "", ErrPasteUnsupported
+	}
+
+	cmd := exec.Command(c.paste[0], c.paste[1:]...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+
+	return string(out), err
+}
+
+// OSC52 is a write-only Clipboard using the OSC 52 terminal escape, which also works over ssh
+type OSC52 struct {
+	w    io.Writer
+	tmux bool
+}
+
+// NewOSC52 returns an OSC52 Clipboard writing to the terminal w, tmux requires the passthrough to be wrapped
+func NewOSC52(w io.Writer, tmux bool) *OSC52 {
+	return &OSC52{w: w, tmux: tmux}
+}
+
+// Copy sets the clipboard to text, "" clears it
+func (o *OSC52) Copy(text string) error {
+	// anything not base64 clears the selection
+	data := "!"
+	if text != "" {
+		data = base64.StdEncoding.EncodeToString([]byte(text))
+	}
+	seq := fmt.Sprintf("\x1b]52;c;%s\a", data)
+	if o.tmux {
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+
+	_, err := io.WriteString(o.w, seq)
+
+	return err
+}
+
+// Paste is unsupported, as terminals rarely allow querying the clipboard
+func (o *OSC52) Paste() (string, error) {
+	return "", ErrPasteUnsupported
+}
+
+// Digest returns the digest Restore() uses to recognize the copied text, without having to hold on to it
+func Digest(text string) string {
+	sum := sha256.Sum256([]byte(text))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Restore puts previous back onto the clipboard ("" clears it), provided it still holds the text matching digest.
+//
+//   NOTE: write-only clipboards are restored unconditionally
+func Restore(c Clipboard, digest, previous string) error {
+	current, err := c.Paste()
+	if err != nil && err != ErrPasteUnsupported {
+		return err
+	}
+	// the user has since copied something else, wl-paste and friends may append a newline
+	if err == nil && Digest(current) != digest && Digest(strings.TrimSuffix(current, "\n")) != digest {
+		return nil
+	}
+
+	return c.Copy(previous)
+}
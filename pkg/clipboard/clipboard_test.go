@@ -0,0 +1,107 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package clipboard_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/clipboard"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSC52(t *testing.T) {
+	var buf bytes.Buffer
+
+	o := clipboard.NewOSC52(&buf, false)
+	assert.NoError(t, o.Copy("Jejr5[RepuSosp"))
+	assert.Equal(t, "\x1b]52;c;SmVqcjVbUmVwdVNvc3A=\a", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, o.Copy(""))
+	assert.Equal(t, "\x1b]52;c;!\a", buf.String())
+
+	buf.Reset()
+	o = clipboard.NewOSC52(&buf, true)
+	assert.NoError(t, o.Copy("Jejr5[RepuSosp"))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;SmVqcjVbUmVwdVNvc3A=\a\x1b\\", buf.String())
+
+	_, err := o.Paste()
+	assert.Equal(t, clipboard.ErrPasteUnsupported, err)
+
+	// write-only clipboards are cleared unconditionally
+	buf.Reset()
+	assert.NoError(t, clipboard.Restore(o, clipboard.Digest("other"), ""))
+	assert.Equal(t, "\x1bPtmux;\x1b\x1b]52;c;!\a\x1b\\", buf.String())
+}
+
+func TestCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gompw-clipboard-")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// fake helpers, storing the clipboard in a file
+	clip := filepath.Join(dir, "clip")
+	copyCmd := filepath.Join(dir, "copy")
+	pasteCmd := filepath.Join(dir, "paste")
+	assert.NoError(t, ioutil.WriteFile(copyCmd, []byte("#!/bin/sh\ncat > "+clip+"\n"), 0700))
+	assert.NoError(t, ioutil.WriteFile(pasteCmd, []byte("#!/bin/sh\ncat "+clip+"\necho\n"), 0700))
+
+	_, err = clipboard.NewCommand(" ", "")
+	assert.Equal(t, clipboard.ErrCommandEmpty, err)
+
+	c, err := clipboard.NewCommand(copyCmd, pasteCmd)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, c.Copy("previous"))
+	assert.NoError(t, c.Copy("Jejr5[RepuSosp"))
+	pasted, err := c.Paste()
+	assert.NoError(t, err)
+	assert.Equal(t, "Jejr5[RepuSosp\n", pasted)
+
+	// restored, as the clipboard still holds the password
+	digest := clipboard.Digest("Jejr5[RepuSosp")
+	assert.NoError(t, clipboard.Restore(c, digest, "previous"))
+	b, _ := ioutil.ReadFile(clip)
+	assert.Equal(t, "previous", string(b))
+
+	// left alone, as the user has since copied something else
+	assert.NoError(t, c.Copy("newer"))
+	assert.NoError(t, clipboard.Restore(c, digest, "previous"))
+	b, _ = ioutil.ReadFile(clip)
+	assert.Equal(t, "newer", string(b))
+
+	c, err = clipboard.NewCommand(copyCmd, "")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = c.Paste()
+	assert.Equal(t, clipboard.ErrPasteUnsupported, err)
+	assert.NoError(t, clipboard.Restore(c, digest, ""))
+	b, _ = ioutil.ReadFile(clip)
+	assert.Equal(t, "", string(b))
+}
@@ -28,6 +28,9 @@ const (
 	DefaultCounter        = 1
 	DefaultPasswordType   = "long"
 
+	// clipboard
+	DefaultClipboardTimeout = 45 // seconds
+
 	// crypto
 	DefaultMasterPasswordSeed = "com.lyndir.masterpassword"
 	DefaultPasswordPurpose    = "auth"
@@ -0,0 +1,36 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+// ClipboardConfig is the intermediate struct for the [clipboard] table, used by --clip
+//
+//   [clipboard]
+//   copy = "xclip -selection clipboard -in"
+//   paste = "xclip -selection clipboard -out"
+//   timeout = 45
+//
+//   NOTE: without a copy command, OSC 52 terminal escapes are used instead
+//   NOTE: without a paste command, the clipboard is cleared rather than restored
+type ClipboardConfig struct {
+	Copy    string `toml:"copy,omitempty"`
+	Paste   string `toml:"paste,omitempty"`
+	Timeout uint32 `toml:"timeout,omitempty"` // seconds
+}
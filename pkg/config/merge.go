@@ -34,11 +34,23 @@ var (
 		// use a func() to ensure it stays pristine on invocation during tests
 		return fields2MergeT{
 			"MasterPasswordSeed": struct{}{},
+			"AlgorithmVersion":   struct{}{},
 			"PasswordType":       struct{}{},
 			"Fullname":           struct{}{},
 			"Password":           struct{}{},
 			"PasswordPurpose":    struct{}{},
 			"Site":               struct{}{},
+			"KeyContext":         struct{}{},
+			"LoginName":          struct{}{},
+			"KeyID":              struct{}{},
+			"Pinentry":           struct{}{},
+			"BreachCorpus":       struct{}{},
+			"Sites":              struct{}{},
+			"Types":              struct{}{},
+			"Clipboard":          struct{}{},
+			"Debug":              struct{}{},
+			"Words":              struct{}{},
+			"Policy":             struct{}{},
 			"Counter":            struct{}{},
 		}
 	}
@@ -53,10 +65,11 @@ func (mpc *MPConfig) Merge(c *MPConfig) {
 		fields2merge = testFields2Merge
 	}
 
-	// These are whitelisted fields used for internal debugging
+	// These are whitelisted fields used for internal debugging and loading
 	whitelisted := map[string]bool{
 		"ConfigFile": true,
 		"dump":       true,
+		"permMode":   true,
 	}
 
 	v := reflect.ValueOf(mpc).Elem()
@@ -79,6 +92,9 @@ func (mpc *MPConfig) Merge(c *MPConfig) {
 	if mpc.MasterPasswordSeed == "" {
 		mpc.MasterPasswordSeed = c.MasterPasswordSeed
 	}
+	if mpc.AlgorithmVersion == nil {
+		mpc.AlgorithmVersion = c.AlgorithmVersion
+	}
 	if mpc.PasswordType == "" {
 		mpc.PasswordType = c.PasswordType
 	}
@@ -94,6 +110,37 @@ func (mpc *MPConfig) Merge(c *MPConfig) {
 	if mpc.Site == "" {
 		mpc.Site = c.Site
 	}
+	if mpc.KeyContext == "" {
+		mpc.KeyContext = c.KeyContext
+	}
+	if mpc.LoginName == "" {
+		mpc.LoginName = c.LoginName
+	}
+	if mpc.KeyID == "" {
+		mpc.KeyID = c.KeyID
+	}
+	if mpc.Pinentry == "" {
+		mpc.Pinentry = c.Pinentry
+	}
+	if mpc.BreachCorpus == "" {
+		mpc.BreachCorpus = c.BreachCorpus
+	}
+	if mpc.Sites == nil {
+		mpc.Sites = c.Sites
+	}
+	if mpc.Types == nil {
+		mpc.Types = c.Types
+	}
+	if mpc.Clipboard == nil {
+		mpc.Clipboard = c.Clipboard
+	}
+	if mpc.Debug == nil {
+		mpc.Debug = c.Debug
+	}
+	mpc.Words = mergeWords(mpc.Words, c.Words)
+	if mpc.Policy == nil {
+		mpc.Policy = c.Policy
+	}
 	if mpc.Counter == 0 {
 		mpc.Counter = c.Counter
 	}
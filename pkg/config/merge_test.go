@@ -25,6 +25,7 @@ import (
 
 	"github.com/TerraTech/go-MasterPassword/pkg/config"
 	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -32,16 +33,35 @@ import (
 //
 // This needs to be run in the 'config_test' public context to avoid an import cycle
 func TestMergeGood(t *testing.T) {
+	var algorithmVersion uint32 // 0 is valid and must still be merged
+	var separator string        // "" is valid and must still be merged
+
 	m := &crypto.MasterPW{
 		Config: &config.MPConfig{}, // simulate what toml.Unmarshal will do to MPConfig on missing config items
 	}
 	c := &config.MPConfig{
 		MasterPasswordSeed: "masterpasswordseed",
+		AlgorithmVersion:   &algorithmVersion,
 		PasswordType:       "passwordtype",
 		Fullname:           "fullname",
 		Password:           "password",
 		Site:               "site",
-		Counter:            69,
+		KeyContext:         "keycontext",
+		LoginName:          "loginname",
+		KeyID:              "keyid",
+		Pinentry:           "pinentry",
+		BreachCorpus:       "breachcorpus",
+		Sites: map[string]*config.SiteConfig{
+			"site": {Counter: 69},
+		},
+		Types: map[string]*config.TypeConfig{
+			"type": {Templates: []string{"nnnn"}},
+		},
+		Clipboard: &config.ClipboardConfig{Copy: "copy", Paste: "paste", Timeout: 69},
+		Debug:     &config.DebugConfig{Scope: []string{"all"}, Level: "info", Format: "json", Output: "stderr"},
+		Policy:    &policy.Policy{MinLength: 69},
+		Words:     &config.WordsConfig{Separator: &separator, Case: "case", Count: 69},
+		Counter:   69,
 	}
 
 	m.Config.Merge(c)
@@ -22,6 +22,7 @@ package config
 
 import (
 	"github.com/TerraTech/go-MasterPassword/pkg/common"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
 )
 
 // Defaults for new MPConfig structs
@@ -34,16 +35,29 @@ const (
 //
 // userConfig =unmarshal=> MPConfig =merge=> MasterPW
 type MPConfig struct {
-	MasterPasswordSeed string `toml:"masterPasswordSeed,omitempty"`
-	PasswordPurpose    string `toml:"passwordPurpose,omitempty"`
-	PasswordType       string `toml:"passwordType,omitempty"`
-	Fullname           string `toml:"fullname,omitempty"`
-	Password           string `toml:"password,omitempty"`
-	Site               string `toml:"site,omitempty"`
-	ConfigFile         string // reordered for struct alignment
-	Counter            uint32 `toml:"counter,omitempty"` // Counter >= 1
+	MasterPasswordSeed string                 `toml:"masterPasswordSeed,omitempty"`
+	PasswordPurpose    string                 `toml:"passwordPurpose,omitempty"`
+	PasswordType       string                 `toml:"passwordType,omitempty"`
+	Fullname           string                 `toml:"fullname,omitempty"`
+	Password           string                 `toml:"password,omitempty"`
+	Site               string                 `toml:"site,omitempty"`
+	KeyContext         string                 `toml:"keyContext,omitempty"`
+	LoginName          string                 `toml:"loginName,omitempty"`
+	KeyID              string                 `toml:"keyID,omitempty"`            // master key ID verification
+	AlgorithmVersion   *uint32                `toml:"algorithmVersion,omitempty"` // nil == unset, as 0 is a valid version
+	Pinentry           string                 `toml:"pinentry,omitempty"`         // master password input via pinentry
+	BreachCorpus       string                 `toml:"breachCorpus,omitempty"`     // offline Pwned Passwords corpus, see pkg/breach
+	ConfigFile         string                 // reordered for struct alignment
+	Sites              map[string]*SiteConfig `toml:"-"`                   // [sites."example.com"], see loadSites()
+	Types              map[string]*TypeConfig `toml:"-"`                   // [types."name"], see loadTypes()
+	Clipboard          *ClipboardConfig       `toml:"clipboard,omitempty"` // [clipboard]
+	Debug              *DebugConfig           `toml:"debug,omitempty"`     // [debug]
+	Words              *WordsConfig           `toml:"words,omitempty"`     // [words]
+	Policy             *policy.Policy         `toml:"policy,omitempty"`    // [policy]
+	Counter            uint32                 `toml:"counter,omitempty"`   // Counter >= 1
 	//
-	dump bool
+	dump     bool
+	permMode PermMode // see checkPerms()
 }
 
 // NewMPConfig returns a new MPConfig with defaults set
@@ -0,0 +1,120 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"futurequest.net/FQgolibs/FQfile"
+)
+
+// PermMode selects how LoadConfig() treats a config file, holding the master password, that others can access.
+//
+//   As ssh does for private keys, the file must neither be group/world accessible nor be owned by another user.
+type PermMode int
+
+// PermMode(s)
+const (
+	PermsEnforce PermMode = iota // refuse to load it (default)
+	PermsWarn                    // only warn, e.g. for CI (MP_CONFIG_PERMS=warn)
+	PermsFix                     // chmod go-rwx (--fix-perms)
+)
+
+var permModes = []string{"enforce", "warn", "fix"}
+
+// ErrPermModeInvalid is returned by ParsePermMode() for an unknown mode
+var ErrPermModeInvalid = errors.New("Config permission mode must be one of: enforce, warn, fix")
+
+// ParsePermMode returns the PermMode of name, "" being PermsEnforce
+func ParsePermMode(name string) (PermMode, error) {
+	if name == "" {
+		return PermsEnforce, nil
+	}
+	for i, m := range permModes {
+		if name == m {
+			return PermMode(i), nil
+		}
+	}
+
+	return PermsEnforce, ErrPermModeInvalid
+}
+
+func (m PermMode) String() string {
+	if m < 0 || int(m) >= len(permModes) {
+		return fmt.Sprintf("PermMode(%d)", int(m))
+	}
+
+	return permModes[m]
+}
+
+// SetPermMode sets how LoadConfig() treats an insecure config file holding the master password
+func (c *MPConfig) SetPermMode(m PermMode) {
+	c.permMode = m
+}
+
+// checkPerms verifies configFile's ownership and permissions, if it holds the master password
+func (c *MPConfig) checkPerms(configFile string) error {
+	if c.Password == "" || !checkPermsSupported {
+		return nil
+	}
+
+	fi, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+
+	if uid, ok := fileOwner(fi); ok && uid != os.Geteuid() && uid != 0 {
+		// can't be fixed by chmod
+		err = fmt.Errorf("gompw config file '%s' holds the master password, but is owned by uid %d", configFile, uid)
+		if c.permMode != PermsWarn {
+			return err
+		}
+		log.Printf("[WARNING] %s", err)
+	}
+
+	pb := FQfile.FileMode(fi.Mode())
+	if !(pb.GroupRead() || pb.GroupWrite() || pb.GroupExecute() || pb.OtherRead() || pb.OtherWrite() || pb.OtherExecute()) {
+		return nil
+	}
+
+	switch c.permMode {
+	case PermsFix:
+		pb.SetGroupRead(false)
+		pb.SetGroupWrite(false)
+		pb.SetGroupExecute(false)
+		pb.SetOtherRead(false)
+		pb.SetOtherWrite(false)
+		pb.SetOtherExecute(false)
+		if err = FQfile.Chmod(configFile, pb); err != nil {
+			return err
+		}
+		log.Printf("[NOTICE] gompw config file '%s': permissions fixed to %04o", configFile, fi.Mode().Perm()&^0077)
+		return nil
+	case PermsWarn:
+		log.Printf("[WARNING] gompw config file '%s' holds the master password, but its permissions %04o are too open", configFile, fi.Mode().Perm())
+		return nil
+	}
+
+	return fmt.Errorf("gompw config file '%s' holds the master password, but its permissions %04o are too open (chmod 600 or use --fix-perms)", configFile, fi.Mode().Perm())
+}
@@ -0,0 +1,62 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+import (
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
+)
+
+// SiteConfig is the intermediate struct for the per-site tables
+//
+//   [sites."example.com"]
+//   counter = 3
+//   passwordType = "maximum"
+type SiteConfig struct {
+	PasswordPurpose  string         `toml:"passwordPurpose,omitempty"`
+	PasswordType     string         `toml:"passwordType,omitempty"`
+	LoginName        string         `toml:"loginName,omitempty"`
+	KeyContext       string         `toml:"keyContext,omitempty"`
+	AlgorithmVersion *uint32        `toml:"algorithmVersion,omitempty"`
+	Words            *WordsConfig   `toml:"words,omitempty"`  // [sites."example.com".words]
+	Policy           *policy.Policy `toml:"policy,omitempty"` // [sites."example.com".policy]
+	Counter          uint32         `toml:"counter,omitempty"`
+}
+
+// SiteConfig returns a MPConfig primed from the matching [sites."site"] table.
+//
+// An empty MPConfig is returned if there is no matching table, so it is always safe to Merge().
+func (c *MPConfig) SiteConfig(site string) *MPConfig {
+	sc, ok := c.Sites[site]
+	if !ok || sc == nil {
+		return &MPConfig{}
+	}
+
+	return &MPConfig{
+		PasswordPurpose:  sc.PasswordPurpose,
+		PasswordType:     sc.PasswordType,
+		LoginName:        sc.LoginName,
+		KeyContext:       sc.KeyContext,
+		AlgorithmVersion: sc.AlgorithmVersion,
+		Words:            sc.Words,
+		Policy:           sc.Policy,
+		Counter:          sc.Counter,
+	}
+}
@@ -0,0 +1,120 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+)
+
+// AppendSites appends a [sites."name"] table for each of sites to the gompw config file, creating it (0600) if needed.
+//
+//   Sites already having a table within file are left untouched and returned as skipped.
+//   Only the passwordPurpose, passwordType, loginName, keyContext, algorithmVersion and counter keys are written.
+func AppendSites(file string, sites map[string]*SiteConfig) ([]string, error) {
+	existing := &MPConfig{}
+	buf, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(buf) != 0 {
+		tree, err := toml.LoadBytes(buf)
+		if err != nil {
+			return nil, err
+		}
+		if err = existing.loadSites(tree); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(sites))
+	for name := range sites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b bytes.Buffer
+	var skipped []string
+	for _, name := range names {
+		if _, ok := existing.Sites[name]; ok {
+			skipped = append(skipped, name)
+			continue
+		}
+		writeSiteTable(&b, name, sites[name])
+	}
+	if b.Len() == 0 {
+		return skipped, nil
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	out := b.Bytes()
+	switch {
+	case len(buf) == 0:
+		out = out[1:]
+	case buf[len(buf)-1] != '\n':
+		out = append([]byte{'\n'}, out...)
+	}
+	if _, err = f.Write(out); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return skipped, f.Close()
+}
+
+// writeSiteTable renders the [sites."name"] table of sc, preceded by a blank line
+func writeSiteTable(b *bytes.Buffer, name string, sc *SiteConfig) {
+	fmt.Fprintf(b, "\n[sites.%s]\n", tomlString(name))
+	if sc.PasswordPurpose != "" {
+		fmt.Fprintf(b, "passwordPurpose = %s\n", tomlString(sc.PasswordPurpose))
+	}
+	if sc.PasswordType != "" {
+		fmt.Fprintf(b, "passwordType = %s\n", tomlString(sc.PasswordType))
+	}
+	if sc.LoginName != "" {
+		fmt.Fprintf(b, "loginName = %s\n", tomlString(sc.LoginName))
+	}
+	if sc.KeyContext != "" {
+		fmt.Fprintf(b, "keyContext = %s\n", tomlString(sc.KeyContext))
+	}
+	if sc.AlgorithmVersion != nil {
+		fmt.Fprintf(b, "algorithmVersion = %d\n", *sc.AlgorithmVersion)
+	}
+	if sc.Counter != 0 {
+		fmt.Fprintf(b, "counter = %d\n", sc.Counter)
+	}
+}
+
+// tomlString quotes s as a TOML basic string, whose escapes are a superset of JSON's
+func tomlString(s string) string {
+	buf, _ := json.Marshal(s)
+
+	return string(buf)
+}
@@ -0,0 +1,72 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendSites(t *testing.T) {
+	var v2 uint32 = 2
+	sites := map[string]*config.SiteConfig{
+		"github.com":      {PasswordType: "long", Counter: 1},
+		"new.example.com": {PasswordType: "maximum", LoginName: "\"quoted\" user", AlgorithmVersion: &v2, Counter: 4},
+		"a.example.com":   {PasswordType: "pin", Counter: 1},
+	}
+
+	cf, cleanup := testConfigFile(t, "gompw-sites.toml")
+	defer cleanup()
+	skipped, err := config.AppendSites(cf, sites)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"github.com"}, skipped)
+
+	c := &config.MPConfig{}
+	if !assert.NoError(t, c.LoadConfig(cf)) {
+		return
+	}
+	assert.Equal(t, &config.SiteConfig{Counter: 3, PasswordType: "maximum", LoginName: "testuser"}, c.Sites["github.com"])
+	assert.Equal(t, sites["new.example.com"], c.Sites["new.example.com"])
+	assert.Equal(t, sites["a.example.com"], c.Sites["a.example.com"])
+	assert.Equal(t, "liveLifeToTheEdge", c.Password)
+
+	// appending again is a no-op
+	skipped, err = config.AppendSites(cf, sites)
+	assert.NoError(t, err)
+	assert.Len(t, skipped, 3)
+
+	// a new file is created, only accessible by its owner
+	cf = filepath.Join(filepath.Dir(cf), "new.toml")
+	_, err = config.AppendSites(cf, sites)
+	assert.NoError(t, err)
+	buf, err := ioutil.ReadFile(cf)
+	assert.NoError(t, err)
+	assert.Equal(t, "[sites.\"a.example.com\"]\npasswordType = \"pin\"\ncounter = 1\n", string(buf[:strings.Index(string(buf), "\n\n")+1]))
+	fi, err := os.Stat(cf)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
@@ -22,30 +22,94 @@ package config
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"text/template"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/debug"
 )
 
 const mpconfig = `-----------------
 configFile         : {{ddd .ConfigFile}}
 masterPasswordSeed : {{ddd .MasterPasswordSeed}}
+algorithmVersion   : {{ptoa .AlgorithmVersion | ddd}}
 fullName           : {{ddd .Fullname}}
-password           : {{ddd .Password}}
+password           : {{redact .Password | ddd}}
+keyID              : {{ddd .KeyID}}
 passwordType       : {{ddd .PasswordType}}
 siteName           : {{ddd .Site}}
 siteCounter        : {{itoa .Counter | ddd}}
+keyContext         : {{ddd .KeyContext}}
+loginName          : {{ddd .LoginName}}
+pinentry           : {{ddd .Pinentry}}
+breachCorpus       : {{ddd .BreachCorpus}}
+{{- range $name, $s := .Sites}}
+-- [sites."{{$name}}"]
+  algorithmVersion : {{ptoa $s.AlgorithmVersion | ddd}}
+  passwordPurpose  : {{ddd $s.PasswordPurpose}}
+  passwordType     : {{ddd $s.PasswordType}}
+  siteCounter      : {{itoa $s.Counter | ddd}}
+  keyContext       : {{ddd $s.KeyContext}}
+  loginName        : {{ddd $s.LoginName}}
+{{- with $s.Words}}
+  words            : {{template "words" .}}
+{{- end}}
+{{- with $s.Policy}}
+  policy           : {{.}}
+{{- end}}
+{{- end}}
+{{- range $name, $t := .Types}}
+-- [types."{{$name}}"]
+  templates        : {{join $t.Templates ", "}}
+{{- range $class, $chars := $t.Classes}}
+  classes.{{$class}}        : {{$chars}}
+{{- end}}
+{{- end}}
+{{- with .Clipboard}}
+-- [clipboard]
+  copy             : {{ddd .Copy}}
+  paste            : {{ddd .Paste}}
+  timeout          : {{itoa .Timeout | ddd}}
+{{- end}}
+{{- with .Debug}}
+-- [debug]
+  scope            : {{join .Scope ", " | ddd}}
+  level            : {{ddd .Level}}
+  format           : {{ddd .Format}}
+  output           : {{ddd .Output}}
+{{- end}}
+{{- with .Words}}
+-- [words]
+  words            : {{template "words" .}}
+{{- end}}
+{{- with .Policy}}
+-- [policy]
+  policy           : {{.}}
+{{- end}}
 -----------------
+{{- define "words"}}count={{itoa .Count | ddd}} separator={{if .Separator}}{{stoa .Separator | printf "%q"}}{{else}}...{{end}} case={{ddd .Case}}{{end}}
 `
 
 var funcMap = template.FuncMap{
-	"ddd":  ddd,
-	"itoa": itoa,
+	"ddd":    ddd,
+	"itoa":   itoa,
+	"join":   strings.Join,
+	"ptoa":   ptoa,
+	"redact": debug.Redact,
+	"stoa":   stoa,
 }
 
 // Dump will dump formatted output of the user configuration file.
+//
+//   The password is redacted according to debug.SetRedaction()
 func (c *MPConfig) Dump() error {
+	return c.dumpTo(os.Stderr)
+}
+
+func (c *MPConfig) dumpTo(w io.Writer) error {
 	t := template.Must(template.New("mpconfig").Funcs(funcMap).Parse(mpconfig))
-	err := t.Execute(os.Stderr, c)
+	err := t.Execute(w, c)
 
 	return err
 }
@@ -66,3 +130,21 @@ func itoa(v uint32) string {
 
 	return fmt.Sprintf("%v", v)
 }
+
+// ptoa = pointer itoa, where nil is unset and 0 is a valid value
+func ptoa(v *uint32) string {
+	if v == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%v", *v)
+}
+
+// stoa = pointer string, where nil is unset and "" is a valid value
+func stoa(v *string) string {
+	if v == nil {
+		return ""
+	}
+
+	return *v
+}
@@ -0,0 +1,52 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/debug"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpRedacted(t *testing.T) {
+	c := &MPConfig{Fullname: "Robert Lee Mitchell", Password: "banana colored duckling"}
+
+	var buf bytes.Buffer
+	assert.NoError(t, c.dumpTo(&buf))
+	assert.Contains(t, buf.String(), "fullName           : Robert Lee Mitchell")
+	assert.Contains(t, buf.String(), "password           : [REDACTED]")
+	assert.NotContains(t, buf.String(), "duckling")
+
+	c.Password = ""
+	buf.Reset()
+	assert.NoError(t, c.dumpTo(&buf))
+	assert.Contains(t, buf.String(), "password           : ...")
+
+	assert.NoError(t, debug.SetRedaction(debug.RedactID))
+	defer debug.SetRedaction(debug.RedactFull)
+	c.Password = "banana colored duckling"
+	buf.Reset()
+	assert.NoError(t, c.dumpTo(&buf))
+	assert.Contains(t, buf.String(), "password           : [ID:")
+	assert.NotContains(t, buf.String(), "duckling")
+}
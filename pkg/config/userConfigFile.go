@@ -66,6 +66,8 @@ func Gcfn(f, home string, abort <-chan struct{}) <-chan string {
 }
 
 // LoadConfig will load and toml.Unmarshal the given configFile
+//
+//   A configFile holding the master password must not be accessible by others, see SetPermMode()
 func (c *MPConfig) LoadConfig(configFile string) error {
 	var t []byte
 	var err error
@@ -102,11 +104,29 @@ func (c *MPConfig) LoadConfig(configFile string) error {
 		return nil
 	}
 
-	// stuff away c.dump since Unmarshal will clobber it
+	// stuff away c.dump and c.permMode since Unmarshal will clobber them
 	doDump := c.dump
+	permMode := c.permMode
 
 	// Needs pelletier/go-toml >= 4a000a21a414d139727f616a8bb97f847b1b310b
-	err = toml.Unmarshal(t, c)
+	tree, err := toml.LoadBytes(t)
+	if err != nil {
+		return err
+	}
+	err = tree.Unmarshal(c)
+	if err != nil {
+		return err
+	}
+	err = c.loadSites(tree)
+	if err != nil {
+		return err
+	}
+	err = c.loadTypes(tree)
+	if err != nil {
+		return err
+	}
+	c.permMode = permMode
+	err = c.checkPerms(configFile)
 	if err != nil {
 		return err
 	}
@@ -134,3 +154,69 @@ func (c *MPConfig) LoadConfig(configFile string) error {
 
 	return nil
 }
+
+// loadSites will unmarshal the [sites."example.com"] tables.
+func (c *MPConfig) loadSites(tree *toml.Tree) error {
+	return loadTables(tree, "sites", func(site string, st *toml.Tree) error {
+		sc := &SiteConfig{}
+		if err := st.Unmarshal(sc); err != nil {
+			return err
+		}
+		if c.Sites == nil {
+			c.Sites = make(map[string]*SiteConfig)
+		}
+		c.Sites[site] = sc
+		return nil
+	})
+}
+
+// loadTypes will unmarshal the [types."name"] tables.
+func (c *MPConfig) loadTypes(tree *toml.Tree) error {
+	return loadTables(tree, "types", func(name string, tt *toml.Tree) error {
+		tc := &TypeConfig{}
+		if err := tt.Unmarshal(tc); err != nil {
+			return err
+		}
+		if classes, ok := tt.Get("classes").(*toml.Tree); ok {
+			tc.Classes = make(map[string]string)
+			for _, class := range classes.Keys() {
+				chars, ok := classes.GetPath([]string{class}).(string)
+				if !ok {
+					return fmt.Errorf("character class '%s' must be a string", class)
+				}
+				tc.Classes[class] = chars
+			}
+		}
+		if c.Types == nil {
+			c.Types = make(map[string]*TypeConfig)
+		}
+		c.Types[name] = tc
+		return nil
+	})
+}
+
+// loadTables calls load for each of the [key."name"] tables.
+//
+// go-toml's Unmarshal of maps uses Tree.Get(), which splits dotted keys, so the
+// names have to be looked up as a single path element instead.
+func loadTables(tree *toml.Tree, key string, load func(name string, t *toml.Tree) error) error {
+	if !tree.Has(key) {
+		return nil
+	}
+	tables, ok := tree.Get(key).(*toml.Tree)
+	if !ok {
+		return fmt.Errorf("gompw config '%s' must be a table", key)
+	}
+
+	for _, name := range tables.Keys() {
+		t, ok := tables.GetPath([]string{name}).(*toml.Tree)
+		if !ok {
+			return fmt.Errorf("gompw config '%s.\"%s\"' must be a table", key, name)
+		}
+		if err := load(name, t); err != nil {
+			return fmt.Errorf("gompw config '%s.\"%s\"': %s", key, name, err)
+		}
+	}
+
+	return nil
+}
@@ -21,10 +21,16 @@
 package config_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/TerraTech/go-MasterPassword/pkg/common"
 	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,19 +49,49 @@ func TestGcfn(t *testing.T) {
 	}
 }
 
+// testConfigFile copies the files/ fixture name to a temporary 0600 file, as the checkout's mode
+// would be refused for a config holding the master password
+func testConfigFile(t *testing.T, name string) (string, func()) {
+	buf, err := ioutil.ReadFile(filepath.Join("../../files", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gompw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cf := filepath.Join(dir, name)
+	if err = ioutil.WriteFile(cf, buf, 0600); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return cf, func() { os.RemoveAll(dir) }
+}
+
 func TestLoadConfig(t *testing.T) {
 	var c *config.MPConfig = &config.MPConfig{}
+	var algorithmVersion uint32 = 2
+	var separator = "-"
 
 	expected := &config.MPConfig{
 		MasterPasswordSeed: "overrideDefaultMPWseed",
+		AlgorithmVersion:   &algorithmVersion,
 		Fullname:           "TestUser",
 		Password:           "liveLifeToTheEdge",
+		KeyID:              "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302",
 		PasswordType:       "maximum",
 		Site:               "FutureQuest.net",
+		Pinentry:           "pinentry-curses",
+		BreachCorpus:       "/var/lib/pwned-passwords",
+		Clipboard:          &config.ClipboardConfig{Copy: "xclip -selection clipboard -in", Timeout: 30},
+		Debug:              &config.DebugConfig{Scope: []string{"crypto/masterKey.go"}, Level: "info", Format: "json", Output: "/tmp/gompw.trace"},
+		Words:              &config.WordsConfig{Separator: &separator, Case: "title"},
 		Counter:            69,
 	}
 
-	cf := "../../files/gompw.toml"
+	cf, cleanup := testConfigFile(t, "gompw.toml")
+	defer cleanup()
 	expected.ConfigFile = cf
 	err := c.LoadConfig(cf)
 	assert.NoError(t, err)
@@ -63,11 +99,19 @@ func TestLoadConfig(t *testing.T) {
 
 	// test 'Counter' and 'PasswordType' defaults when 'omitempty'
 	expected.MasterPasswordSeed = ""
+	expected.AlgorithmVersion = nil
+	expected.KeyID = ""
+	expected.Pinentry = ""
+	expected.BreachCorpus = ""
+	expected.Clipboard = nil
+	expected.Debug = nil
+	expected.Words = nil
 	expected.Counter = 1
 	expected.PasswordType = "long"
 
 	c = config.NewMPConfig()
-	cf = "../../files/gompw-omitempty.toml"
+	cf, cleanup = testConfigFile(t, "gompw-omitempty.toml")
+	defer cleanup()
 	expected.ConfigFile = cf
 	err = c.LoadConfig(cf)
 	assert.NoError(t, err)
@@ -96,9 +140,126 @@ func TestMerge(t *testing.T) {
 		Counter:      1,
 	}
 
-	cf := "../../files/gompw-merge.toml"
+	cf, cleanup := testConfigFile(t, "gompw-merge.toml")
+	defer cleanup()
 	expected.ConfigFile = cf
 	err := c.LoadConfig(cf)
 	assert.NoError(t, err)
 	assert.Equal(t, expected, c)
 }
+
+func TestLoadConfigSites(t *testing.T) {
+	var c = &config.MPConfig{}
+	var algorithmVersion uint32 = 2
+	var separator = ""
+
+	cf, cleanup := testConfigFile(t, "gompw-sites.toml")
+	defer cleanup()
+	err := c.LoadConfig(cf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expected := map[string]*config.SiteConfig{
+		"github.com":           {Counter: 3, PasswordType: "maximum", LoginName: "testuser"},
+		"bank.example.com":     {PasswordType: "pin"},
+		"security.example.com": {PasswordPurpose: "rec", PasswordType: "phrase", KeyContext: "first pet", AlgorithmVersion: &algorithmVersion},
+		"vault.example.com":    {PasswordType: "diceware", Words: &config.WordsConfig{Count: 8, Separator: &separator}},
+		"corp.example.com":     {PasswordType: "corp-policy"},
+		"legacy.example.com": {
+			PasswordType: "long",
+			Policy:       &policy.Policy{MaxLength: 10, Require: []string{"digit"}, Forbid: "'\";"},
+		},
+	}
+	assert.Equal(t, expected, c.Sites)
+
+	expectedTypes := map[string]*config.TypeConfig{
+		"corp-policy": {
+			Templates: []string{"CvcvnnCvcvyy", "nnCvcvyyCvcv", "yyCvcvCvcvnn"},
+			Classes:   map[string]string{"y": "@&%?,=[]_:-+*$#!^~()/."},
+		},
+	}
+	assert.Equal(t, expectedTypes, c.Types)
+
+	// flags <= site table <= global
+	m := &config.MPConfig{Counter: 5}
+	m.Merge(c.SiteConfig("github.com"))
+	m.Merge(c)
+	assert.Equal(t, uint32(5), m.Counter)
+	assert.Equal(t, "maximum", m.PasswordType)
+	assert.Equal(t, "testuser", m.LoginName)
+	assert.Equal(t, "TestUser", m.Fullname)
+
+	m = &config.MPConfig{}
+	m.Merge(c.SiteConfig("bank.example.com"))
+	m.Merge(c)
+	assert.Equal(t, uint32(2), m.Counter)
+	assert.Equal(t, "pin", m.PasswordType)
+
+	// [words] fields merge individually: flags <= site table <= global
+	m = &config.MPConfig{Words: &config.WordsConfig{Case: "upper"}}
+	m.Merge(c.SiteConfig("vault.example.com"))
+	m.Merge(&config.MPConfig{Words: &config.WordsConfig{Count: 4, Case: "title"}})
+	assert.Equal(t, &config.WordsConfig{Count: 8, Separator: &separator, Case: "upper"}, m.Words)
+	assert.Equal(t, uint32(8), c.Sites["vault.example.com"].Words.Count, "merge must not modify its source")
+	assert.Empty(t, c.Sites["vault.example.com"].Words.Case)
+
+	// no matching table
+	m = &config.MPConfig{}
+	m.Merge(c.SiteConfig("noexist.com"))
+	m.Merge(c)
+	assert.Equal(t, uint32(2), m.Counter)
+	assert.Equal(t, "medium", m.PasswordType)
+}
+
+func TestLoadConfigPerms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file mode is not enforced on windows")
+	}
+
+	cf, cleanup := testConfigFile(t, "gompw-merge.toml")
+	defer cleanup()
+
+	if err := os.Chmod(cf, 0640); err != nil {
+		t.Fatal(err)
+	}
+	err := config.NewMPConfig().LoadConfig(cf)
+	if assert.Error(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "permissions 0640 are too open"), err.Error())
+	}
+
+	c := config.NewMPConfig()
+	c.SetPermMode(config.PermsWarn)
+	assert.NoError(t, c.LoadConfig(cf))
+	assert.Equal(t, "liveLifeToTheEdge", c.Password)
+
+	c = config.NewMPConfig()
+	c.SetPermMode(config.PermsFix)
+	assert.NoError(t, c.LoadConfig(cf))
+	fi, err := os.Stat(cf)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	assert.NoError(t, config.NewMPConfig().LoadConfig(cf))
+
+	// without a password, the mode doesn't matter
+	cf = filepath.Join(filepath.Dir(cf), "gompw-nopassword.toml")
+	if err = ioutil.WriteFile(cf, []byte("fullname = \"TestUser\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, config.NewMPConfig().LoadConfig(cf))
+}
+
+func TestParsePermMode(t *testing.T) {
+	for _, m := range []config.PermMode{config.PermsEnforce, config.PermsWarn, config.PermsFix} {
+		got, err := config.ParsePermMode(m.String())
+		assert.NoError(t, err)
+		assert.Equal(t, m, got)
+	}
+
+	got, err := config.ParsePermMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, config.PermsEnforce, got)
+
+	_, err = config.ParsePermMode("ignore")
+	assert.Equal(t, config.ErrPermModeInvalid, err)
+}
@@ -0,0 +1,62 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package config
+
+// WordsConfig is the intermediate struct for the [words] table, used by the word list
+// password types (bip39, diceware), and may also be set per site: [sites."example.com".words]
+//
+//   [words]
+//   count = 8
+//   separator = "-"
+//   case = "title"
+//
+//   NOTE: the fields are merged individually, unlike the other tables
+type WordsConfig struct {
+	Separator *string `toml:"separator,omitempty"` // nil == unset, as "" is a valid separator
+	Case      string  `toml:"case,omitempty"`      // lower, title or upper
+	Count     uint32  `toml:"count,omitempty"`     // 0 == the password type's default
+}
+
+// mergeWords merges the unset fields of w from o, without modifying either
+func mergeWords(w, o *WordsConfig) *WordsConfig {
+	if w == nil && o == nil {
+		return nil
+	}
+
+	var m WordsConfig
+	if w != nil {
+		m = *w
+	}
+	if o == nil {
+		return &m
+	}
+	if m.Separator == nil {
+		m.Separator = o.Separator
+	}
+	if m.Case == "" {
+		m.Case = o.Case
+	}
+	if m.Count == 0 {
+		m.Count = o.Count
+	}
+
+	return &m
+}
@@ -0,0 +1,147 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// AlgorithmVersion lookup tokens
+const (
+	AlgorithmVersionUnSet AlgorithmVersion = iota
+	AlgorithmVersion0
+	AlgorithmVersion1
+	AlgorithmVersion2
+	AlgorithmVersion3
+)
+
+// AlgorithmVersionCurrent is the algorithm version used when none has been specified
+const AlgorithmVersionCurrent = AlgorithmVersion3
+
+// AlgorithmVersion exported errors
+var (
+	ErrAlgorithmVersionInvalid = errors.New("Invalid algorithm version, valid versions are: 0, 1, 2, 3")
+)
+
+// AlgorithmVersion selects which revision of the Master Password algorithm is used.
+//
+//   0) (v0) masterKey, siteKey: lengths in characters; password: 16bit seed bytes (signed char + htons bug)
+//   1) (v1) masterKey, siteKey: lengths in characters
+//   2) (v2) masterKey: lengths in characters
+//   3) (v3) *Current*, all lengths are in bytes
+//
+//   NOTE: v0-v2 only differ from v3 for non-ASCII input, except for v0 which always differs.
+type AlgorithmVersion int
+
+func (av AlgorithmVersion) String() string {
+	if av == AlgorithmVersionUnSet {
+		return ""
+	}
+
+	return strconv.Itoa(int(av.Version()))
+}
+
+// Version returns the numeric algorithm version represented by the token
+func (av AlgorithmVersion) Version() uint32 {
+	return uint32(av - AlgorithmVersion0)
+}
+
+// Validate will test if algorithm version is valid
+func (av AlgorithmVersion) Validate() error {
+	if av < AlgorithmVersion0 || av > AlgorithmVersion3 {
+		return ErrAlgorithmVersionInvalid
+	}
+
+	return nil
+}
+
+// AlgorithmVersionToToken returns the const token associated with given numeric version
+func AlgorithmVersionToToken(version uint32) (AlgorithmVersion, error) {
+	if err := ValidateAlgorithmVersion(version); err != nil {
+		return AlgorithmVersionUnSet, err
+	}
+
+	return AlgorithmVersion0 + AlgorithmVersion(version), nil
+}
+
+// SetAlgorithmVersion sets the MasterPassword's algorithm version
+func (mpw *MasterPW) SetAlgorithmVersion(version uint32) (err error) {
+	var token AlgorithmVersion
+	if token, err = AlgorithmVersionToToken(version); err == nil {
+		mpw.algorithmVersion = token
+	}
+	return
+}
+
+// ValidateAlgorithmVersion will test if given numeric version is valid
+func ValidateAlgorithmVersion(version uint32) error {
+	if version > AlgorithmVersion3.Version() {
+		return ErrAlgorithmVersionInvalid
+	}
+
+	return nil
+}
+
+// ValidateAlgorithmVersion will test if MasterPW algorithm version is valid
+func (mpw *MasterPW) ValidateAlgorithmVersion() error {
+	return mpw.algorithmVersion.Validate()
+}
+
+// fullnameLength returns the encoded length of fullname as used by masterKeySalt.
+//
+//   <v3 counts characters, v3 counts bytes
+func (av AlgorithmVersion) fullnameLength(fullname string) uint32 {
+	if av < AlgorithmVersion3 {
+		return uint32(utf8.RuneCountInString(fullname))
+	}
+
+	return uint32(len(fullname))
+}
+
+// siteLength returns the encoded length of a siteName or keyContext as used by siteSalt.
+//
+//   <v2 counts characters, v2+ counts bytes
+func (av AlgorithmVersion) siteLength(s string) uint32 {
+	if av < AlgorithmVersion2 {
+		return uint32(utf8.RuneCountInString(s))
+	}
+
+	return uint32(len(s))
+}
+
+// seedByte returns the value used to index into the templates and templateCharacters.
+//
+// v0 used htons() upon (const char *) siteKey, thus the seed byte ends up being a byte
+// swapped 16bit value that is sign extended, for the template as well as the character lookups.
+func (av AlgorithmVersion) seedByte(seed []byte, i int) int {
+	if av != AlgorithmVersion0 {
+		return int(seed[i])
+	}
+
+	v := int(seed[i]) << 8
+	if seed[i] > 0x7F {
+		v |= 0xFF
+	}
+
+	return v
+}
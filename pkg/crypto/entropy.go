@@ -0,0 +1,186 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"math"
+	"math/big"
+	"sort"
+)
+
+// TypeStats describes the passwords of a password type, for justifying its choice.
+//
+//   SearchSpace is the number of distinct passwords, whereas Entropy also accounts for the modulo bias
+//   of the site key bytes selecting the template and characters, i.e. Entropy <= log2(SearchSpace).
+//
+//   NOTE: the templates are assumed to generate disjoint passwords, otherwise both are upper bounds
+//   NOTE: calculated for the current algorithm version, v0's 16bit seed bytes are biased differently
+type TypeStats struct {
+	Name        string
+	Charset     string   // all of the characters that may occur, sorted
+	SearchSpace *big.Int // number of distinct passwords
+	Entropy     float64  // Shannon entropy in bits
+	Templates   int      // number of templates, or words for the word list types
+	MinLength   int
+	MaxLength   int
+}
+
+// SearchSpaceBits returns log2(SearchSpace)
+func (ts *TypeStats) SearchSpaceBits() float64 {
+	return log2(ts.SearchSpace)
+}
+
+// PasswordTypeStats returns the TypeStats of passwordType, wf is only used by the word list types (nil uses the defaults)
+func PasswordTypeStats(passwordType string, wf *WordFormat) (*TypeStats, error) {
+	if err := ValidatePasswordType(passwordType); err != nil {
+		return nil, err
+	}
+	passwordType = PasswordTypeName(passwordType)
+	if wf == nil {
+		wf = NewWordFormat(nil)
+	}
+	if err := wf.Validate(passwordType); err != nil {
+		return nil, err
+	}
+
+	if wl, ok := passwordTypeWordLists[passwordType]; ok {
+		return wl.stats(passwordType, wf), nil
+	}
+
+	templates := passwordTypeTemplates[passwordType]
+	ts := &TypeStats{
+		Name:        passwordType,
+		SearchSpace: new(big.Int),
+		Templates:   len(templates),
+		MinLength:   len(templates[0]),
+	}
+	charset := map[byte]bool{}
+	for i, template := range templates {
+		// probability of the template being selected by the site key's first byte
+		p := byteProbability(len(templates), i)
+		ts.Entropy -= p * math.Log2(p)
+
+		space := big.NewInt(1)
+		for _, element := range template {
+			chars := templateClass(passwordType, element)
+			space.Mul(space, big.NewInt(int64(len(chars))))
+			ts.Entropy += p * byteEntropy(len(chars))
+			for j := 0; j < len(chars); j++ {
+				charset[chars[j]] = true
+			}
+		}
+		ts.SearchSpace.Add(ts.SearchSpace, space)
+
+		if len(template) < ts.MinLength {
+			ts.MinLength = len(template)
+		}
+		if len(template) > ts.MaxLength {
+			ts.MaxLength = len(template)
+		}
+	}
+	ts.Charset = sortedCharset(charset)
+
+	return ts, nil
+}
+
+// stats returns the TypeStats of wl formatted by wf
+func (wl *wordList) stats(name string, wf *WordFormat) *TypeStats {
+	count := wf.Count
+	if count == 0 {
+		count = wl.defaultCount
+	}
+	pt := wl.pattern(wf)
+
+	ts := &TypeStats{
+		Name:        name,
+		SearchSpace: wl.searchSpace(wl.words, count),
+		Templates:   int(count),
+		MinLength:   pt.MinLength,
+		MaxLength:   pt.MaxLength,
+	}
+	// the words are selected without bias, diceware's modulo bias being negligible
+	ts.Entropy = log2(ts.SearchSpace)
+
+	charset := map[byte]bool{}
+	for _, word := range wl.words {
+		for _, c := range []byte(wordCase(word, wf.Case)) {
+			charset[c] = true
+		}
+	}
+	if count > 1 {
+		for _, c := range []byte(wf.Separator) {
+			charset[c] = true
+		}
+	}
+	ts.Charset = sortedCharset(charset)
+
+	return ts
+}
+
+// byteProbability returns the probability of a uniformly random byte % n == k
+func byteProbability(n, k int) float64 {
+	count := 256 / n
+	if k < 256%n {
+		count++
+	}
+
+	return float64(count) / 256
+}
+
+// byteEntropy returns the Shannon entropy of a uniformly random byte % n
+func byteEntropy(n int) float64 {
+	var h float64
+	for k := 0; k < n && k < 256; k++ {
+		p := byteProbability(n, k)
+		h -= p * math.Log2(p)
+	}
+
+	return h
+}
+
+// log2 returns log2(n) for n > 0, beyond float64's integer precision
+func log2(n *big.Int) float64 {
+	if n.Sign() <= 0 {
+		return 0
+	}
+	shift := n.BitLen() - 64
+	if shift < 0 {
+		shift = 0
+	}
+	f, _ := new(big.Float).SetInt(new(big.Int).Rsh(n, uint(shift))).Float64()
+
+	return math.Log2(f) + float64(shift)
+}
+
+func sortedCharset(charset map[byte]bool) string {
+	chars := make([]int, 0, len(charset))
+	for c := range charset {
+		chars = append(chars, int(c))
+	}
+	sort.Ints(chars)
+
+	b := make([]byte, len(chars))
+	for i, c := range chars {
+		b[i] = byte(c)
+	}
+
+	return string(b)
+}
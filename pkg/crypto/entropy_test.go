@@ -0,0 +1,96 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordTypeStats(t *testing.T) {
+	expectations := []struct {
+		pt          string
+		searchSpace string
+		bits        float64
+		entropy     float64
+		templates   int
+		minLength   int
+		maxLength   int
+	}{
+		{"maximum", "937339719116841022741442463289835520", 119.4961, 119.2493, 2, 20, 20},
+		{"long", "64183076650350000", 55.8330, 55.2051, 21, 14, 14},
+		{"medium", "2333772000", 31.1200, 31.1153, 2, 8, 8},
+		{"basic", "4334325964800", 41.9789, 41.6283, 3, 8, 8},
+		{"short", "22050", 14.4285, 14.4267, 1, 4, 4},
+		{"i", "10000", 13.2877, 13.2867, 1, 4, 4},
+		{"name", "2552563125", 31.2493, 31.2454, 1, 9, 9},
+		{"phrase", "6838254776033296875", 62.5683, 60.9911, 3, 18, 20},
+		{"bip39", "340282366920938463463374607431768211456", 128, 128, 12, 47, 107},
+		{"diceware", "221073919720733357899776", 77.5489, 77.5489, 6, 23, 59},
+	}
+
+	for _, tv := range expectations {
+		ts, err := crypto.PasswordTypeStats(tv.pt, nil)
+		if !assert.NoError(t, err, tv.pt) {
+			continue
+		}
+		assert.Equal(t, tv.searchSpace, ts.SearchSpace.String(), tv.pt)
+		assert.InDelta(t, tv.bits, ts.SearchSpaceBits(), 0.0001, tv.pt)
+		assert.InDelta(t, tv.entropy, ts.Entropy, 0.0001, tv.pt)
+		assert.Equal(t, tv.templates, ts.Templates, tv.pt)
+		assert.Equal(t, tv.minLength, ts.MinLength, tv.pt)
+		assert.Equal(t, tv.maxLength, ts.MaxLength, tv.pt)
+	}
+
+	ts, err := crypto.PasswordTypeStats("pin", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "0123456789", ts.Charset)
+	}
+	ts, err = crypto.PasswordTypeStats("bip39", &crypto.WordFormat{Separator: "-", Count: 24})
+	if assert.NoError(t, err) {
+		assert.Equal(t, float64(256), ts.Entropy)
+		assert.Equal(t, "-abcdefghijklmnopqrstuvwxyz", ts.Charset)
+	}
+
+	_, err = crypto.PasswordTypeStats("noexist", nil)
+	assert.Equal(t, crypto.ErrPasswordTypeInvalid, err)
+	_, err = crypto.PasswordTypeStats("diceware", &crypto.WordFormat{Separator: " ", Count: 20})
+	assert.Equal(t, crypto.ErrWordCountInvalid, err)
+}
+
+func TestPasswordTypeStatsCustom(t *testing.T) {
+	err := crypto.RegisterPasswordType("stats-test", []string{"hhhh", "hhhhhh"}, map[string]string{"h": "0123456789abcdef"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	ts, err := crypto.PasswordTypeStats("stats-test", nil)
+	if assert.NoError(t, err) {
+		// 16^4 + 16^6, both templates selected with equal probability
+		assert.Equal(t, "16842752", ts.SearchSpace.String())
+		assert.InDelta(t, 21, ts.Entropy, 0.0001)
+		assert.Equal(t, "0123456789abcdef", ts.Charset)
+		assert.Equal(t, 4, ts.MinLength)
+		assert.Equal(t, 6, ts.MaxLength)
+	}
+}
@@ -0,0 +1,105 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// IdenticonColor lookup tokens, matching the ANSI color order
+const (
+	IdenticonColorBlack IdenticonColor = iota
+	IdenticonColorRed
+	IdenticonColorGreen
+	IdenticonColorYellow
+	IdenticonColorBlue
+	IdenticonColorMagenta
+	IdenticonColorCyan
+	IdenticonColorWhite
+)
+
+var (
+	identiconLeftArm   = []string{"╔", "╚", "╰", "═"}
+	identiconRightArm  = []string{"╗", "╝", "╯", "═"}
+	identiconBody      = []string{"█", "░", "▒", "▓", "☺", "☻"}
+	identiconAccessory = []string{
+		"◈", "◎", "◐", "◑", "◒", "◓", "☀", "☁", "☂", "☃", "☄", "★", "☆", "☎", "☏", "⎈", "⌂", "☘", "☢", "☣",
+		"☕", "⌚", "⌛", "⏰", "⚡", "⛄", "⛅", "☔", "♔", "♕", "♖", "♗", "♘", "♙", "♚", "♛", "♜", "♝", "♞", "♟",
+		"♨", "♩", "♪", "♫", "⚐", "⚑", "⚔", "⚖", "⚙", "⚠", "⌘", "⏎", "✄", "✆", "✈", "✉", "✌",
+	}
+
+	icmap = map[IdenticonColor]string{
+		IdenticonColorBlack:   "black",
+		IdenticonColorRed:     "red",
+		IdenticonColorGreen:   "green",
+		IdenticonColorYellow:  "yellow",
+		IdenticonColorBlue:    "blue",
+		IdenticonColorMagenta: "magenta",
+		IdenticonColorCyan:    "cyan",
+		IdenticonColorWhite:   "white",
+	}
+)
+
+// IdenticonColor is the color the MPIdenticon is to be rendered in.
+type IdenticonColor int
+
+func (ic IdenticonColor) String() string {
+	return icmap[ic]
+}
+
+// MPIdenticon is a visual representation of {fullname, password}, allowing for a quick
+// confirmation that the master password was typed correctly.
+type MPIdenticon struct {
+	LeftArm   string
+	Body      string
+	RightArm  string
+	Accessory string
+	Color     IdenticonColor
+}
+
+// Identicon returns the MPIdenticon for {fullname, password} according to the mpw clients:
+//
+//   identiconSeed = hmac-sha256( masterPassword, fullName )
+func Identicon(fullname, password string) *MPIdenticon {
+	hmacv := hmac.New(sha256.New, []byte(password))
+	hmacv.Write([]byte(fullname)) // hash.Hash never returns an error
+	seed := hmacv.Sum(nil)
+
+	return &MPIdenticon{
+		LeftArm:   identiconLeftArm[int(seed[0])%len(identiconLeftArm)],
+		Body:      identiconBody[int(seed[1])%len(identiconBody)],
+		RightArm:  identiconRightArm[int(seed[2])%len(identiconRightArm)],
+		Accessory: identiconAccessory[int(seed[3])%len(identiconAccessory)],
+		Color:     IdenticonColor(int(seed[4])%7 + 1),
+	}
+}
+
+// String returns the MPIdenticon's glyphs without any color
+func (i *MPIdenticon) String() string {
+	return i.LeftArm + i.Body + i.RightArm + i.Accessory
+}
+
+// ANSI returns the MPIdenticon's glyphs wrapped in ANSI color escapes
+func (i *MPIdenticon) ANSI() string {
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", 30+int(i.Color), i.String())
+}
@@ -0,0 +1,50 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdenticon(t *testing.T) {
+	expectations := []struct {
+		u, pw  string
+		expect string
+		color  crypto.IdenticonColor
+	}{
+		{"Robert Lee Mitchell", "banana colored duckling", "╚☻╯⛄", crypto.IdenticonColorGreen},
+		{d.u, d.pw, "╚█═♗", crypto.IdenticonColorRed},
+	}
+
+	for _, e := range expectations {
+		i := crypto.Identicon(e.u, e.pw)
+		assert.Equal(t, e.expect, i.String())
+		assert.Equal(t, e.color, i.Color)
+		assert.Equal(t, e.expect, i.LeftArm+i.Body+i.RightArm+i.Accessory)
+	}
+
+	i := crypto.Identicon("Robert Lee Mitchell", "banana colored duckling")
+	assert.Equal(t, "\x1b[32m╚☻╯⛄\x1b[0m", i.ANSI())
+	assert.Equal(t, "green", i.Color.String())
+}
@@ -0,0 +1,359 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/debug"
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters as defined by: http://masterpasswordapp.com/algorithm.html
+const (
+	scryptN      = 32768
+	scryptR      = 8
+	scryptP      = 2
+	scryptKeyLen = 64
+)
+
+// MasterKey exported errors
+var (
+	ErrMasterKeyDestroyed  = errors.New("Master key has been destroyed")
+	ErrMasterKeyIDMismatch = errors.New("Master key ID mismatch, please check the fullname, master password, seed and algorithm version")
+)
+
+// MasterKey is the scrypt derived key of {fullname, password, seed, algorithm}.
+//
+// Deriving the MasterKey is the expensive part of the algorithm, therefore callers
+// generating multiple site passwords should derive it once and reuse it.
+type MasterKey struct {
+	algorithmVersion   AlgorithmVersion
+	masterPasswordSeed string
+	key                *Secret
+}
+
+// NewMasterKey returns a validated and derived MasterKey
+func NewMasterKey(mpwseed, fullname, password string, algorithmVersion uint32) (*MasterKey, error) {
+	if err := ValidateMasterPasswordSeed(mpwseed); err != nil {
+		return nil, err
+	}
+	av, err := AlgorithmVersionToToken(algorithmVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err = ValidateFullname(fullname); err != nil {
+		return nil, err
+	}
+	if err = ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	pw, err := NewSecretString(password)
+	if err != nil {
+		return nil, err
+	}
+	defer pw.Close()
+
+	return newMasterKey(av, mpwseed, fullname, pw)
+}
+
+// newMasterKey derives the MasterKey, all params are expected to have been validated
+func newMasterKey(av AlgorithmVersion, mpwseed, fullname string, password *Secret) (*MasterKey, error) {
+	// FIXME: convert to template
+	//   Pro: cleans up the code and removes the Dbg() interstitials
+	//   Con: if something panics, might not have reached the template call
+	Dbg("-- mpw_masterKey (algorithm: %s)", av)
+	Dbg("fullName: %s", fullname)
+	Dbg("password: %s", debug.SecretBytes(password.Bytes()))
+	Dbg("masterPassword.id: %s", debug.SecretID(mpwIDBuf(password.Bytes())))
+	Dbg("keyScope: %s", mpwseed)
+	Dbg("masterKeySalt: keyScope=%s | #fullName=%08X | fullName=%s", mpwseed, av.fullnameLength(fullname), fullname)
+
+	var buffer bytes.Buffer
+	buffer.WriteString(mpwseed)
+	if err := binary.Write(&buffer, binary.BigEndian, av.fullnameLength(fullname)); err != nil {
+		return nil, err
+	}
+	buffer.WriteString(fullname)
+
+	salt := buffer.Bytes()
+	Dbg("  => masterKeySalt.id: %s", mpwIDBuf(salt))
+
+	key, err := scrypt.Key(password.Bytes(), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %s", err)
+	}
+	Dbg("masterKey: scrypt( masterPassword, masterKeySalt, N=%d, r=%d, p=%d, keyLen=%d", scryptN, scryptR, scryptP, scryptKeyLen)
+	Dbg("  => masterKey.id: %s", mpwIDBuf(key))
+	DbgL(debug.LevelInfo, "masterKey", "algorithm", av.Version(), "fullName", fullname,
+		"masterPassword.id", debug.SecretID(mpwIDBuf(password.Bytes())), "keyScope", mpwseed,
+		"masterKeySalt.id", mpwIDBuf(salt), "masterKey.id", mpwIDBuf(key))
+
+	// moves (and zeroes) the heap allocated key
+	secret, err := NewSecretBytes(key)
+	if err != nil {
+		zero(key)
+		return nil, err
+	}
+
+	return &MasterKey{
+		algorithmVersion:   av,
+		masterPasswordSeed: mpwseed,
+		key:                secret,
+	}, nil
+}
+
+// ID returns the MasterKey's identifier, as used by the mpw clients to detect a mistyped master password
+func (mk *MasterKey) ID() string {
+	return mpwIDBuf(mk.key.Bytes())
+}
+
+// VerifyID verifies that the MasterKey's identifier matches keyID, "" skips the verification
+func (mk *MasterKey) VerifyID(keyID string) error {
+	if keyID == "" {
+		return nil
+	}
+	if !strings.EqualFold(mk.ID(), keyID) {
+		return ErrMasterKeyIDMismatch
+	}
+
+	return nil
+}
+
+// AlgorithmVersion returns the algorithm version the MasterKey was derived with
+func (mk *MasterKey) AlgorithmVersion() uint32 {
+	return mk.algorithmVersion.Version()
+}
+
+// MasterPasswordSeed returns the master password seed the MasterKey was derived with
+func (mk *MasterKey) MasterPasswordSeed() string {
+	return mk.masterPasswordSeed
+}
+
+// Mlock locks the MasterKey into memory, preventing it from being paged out to swap.
+//
+//   The key's Secret is already locked on a best effort basis, Mlock() reports whether that succeeded.
+//
+//   NOTE: a no-op on platforms lacking mlock(2)
+func (mk *MasterKey) Mlock() error {
+	if mk.key == nil {
+		return ErrMasterKeyDestroyed
+	}
+
+	return mk.key.Mlock()
+}
+
+// Destroy zeroes the MasterKey, after which it can no longer derive site keys
+func (mk *MasterKey) Destroy() {
+	if mk.key == nil {
+		return
+	}
+
+	_ = mk.key.Close()
+	mk.key = nil
+}
+
+// SiteKey returns the hmac-sha256 site key for the given site parameters.
+//
+//   NOTE: keyContext may be "", which will leave the siteSalt unperturbed
+//   NOTE: the returned key is a copy on the heap, which the caller should zero once done
+func (mk *MasterKey) SiteKey(site string, counter uint32, purpose, keyContext string) ([]byte, error) {
+	pp, err := validateSiteParams(site, counter, purpose)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := mk.siteKey(site, counter, pp, keyContext)
+	if err != nil {
+		return nil, err
+	}
+	defer seed.Close()
+
+	return append([]byte(nil), seed.Bytes()...), nil
+}
+
+// SitePassword returns a derived password for the given site parameters.
+//
+//   Valid PasswordTypes: basic, bip39, diceware, long, maximum, medium, name, phrase, pin, short
+func (mk *MasterKey) SitePassword(passwordType, site string, counter uint32, purpose, keyContext string) (string, error) {
+	return mk.SitePasswordEX(passwordType, nil, site, counter, purpose, keyContext)
+}
+
+// SitePasswordEX is SitePassword() with the PasswordOptions, i.e. the word list format and site policy,
+// nil opts uses the defaults.
+func (mk *MasterKey) SitePasswordEX(passwordType string, opts *PasswordOptions, site string, counter uint32, purpose, keyContext string) (string, error) {
+	if err := ValidatePasswordType(passwordType); err != nil {
+		return "", err
+	}
+	if opts != nil {
+		if err := opts.Validate(passwordType); err != nil {
+			return "", err
+		}
+	}
+	pp, err := validateSiteParams(site, counter, purpose)
+	if err != nil {
+		return "", err
+	}
+	seed, err := mk.siteKey(site, counter, pp, keyContext)
+	if err != nil {
+		return "", err
+	}
+	defer seed.Close()
+
+	return mk.sitePassword(passwordType, opts, seed.Bytes())
+}
+
+// siteKey derives the site key into a Secret, all params are expected to have been validated
+func (mk *MasterKey) siteKey(site string, counter uint32, pp PasswordPurpose, keyContext string) (*Secret, error) {
+	if mk.key == nil {
+		return nil, ErrMasterKeyDestroyed
+	}
+	av := mk.algorithmVersion
+	// munge the master password seed depending on password purpose
+	mpseed := mk.masterPasswordSeed + pp.scope()
+
+	Dbg("-- mpw_siteKey (algorithm: %s)", av)
+	Dbg("siteName: %s", site)
+	Dbg("siteCounter: %d", counter)
+	// FIXME: stringer doesn't appear to be working right
+	Dbg("keyPurpose: %d (%s)", pp, pp.String())
+	Dbg("keyContext: %s", orNull(keyContext))
+	Dbg("keyScope: %s", mpseed)
+	Dbg("siteSalt: keyScope=%s | #siteName=%08X | siteName=%s | siteCounter=%08d | #keyContext=%08X | keyContext=%s",
+		mpseed, av.siteLength(site), site, counter, av.siteLength(keyContext), orNull(keyContext))
+
+	var buffer bytes.Buffer
+	buffer.WriteString(mpseed)
+	if err := binary.Write(&buffer, binary.BigEndian, av.siteLength(site)); err != nil {
+		return nil, err
+	}
+	buffer.WriteString(site)
+	if err := binary.Write(&buffer, binary.BigEndian, counter); err != nil {
+		return nil, err
+	}
+	// keyContext is only appended when set, otherwise the siteSalt is unchanged
+	if keyContext != "" {
+		if err := binary.Write(&buffer, binary.BigEndian, av.siteLength(keyContext)); err != nil {
+			return nil, err
+		}
+		buffer.WriteString(keyContext)
+	}
+	Dbg("  => siteSalt.id: %s", mpwIDBuf(buffer.Bytes()))
+
+	Dbg("siteKey: hmac-sha256( masterKey.id=%s, siteSalt )", mpwIDBuf(mk.key.Bytes()))
+	var hmacv = hmac.New(sha256.New, mk.key.Bytes())
+	if _, err := hmacv.Write(buffer.Bytes()); err != nil {
+		return nil, err
+	}
+	seed, err := NewSecret(sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	// the Secret's capacity is exactly sha256.Size, so the sum is written in place
+	hmacv.Sum(seed.Bytes()[:0])
+	Dbg("  => siteKey.id: %s", mpwIDBuf(seed.Bytes()))
+	DbgL(debug.LevelInfo, "siteKey", "algorithm", av.Version(), "siteName", site, "siteCounter", counter,
+		"keyPurpose", pp.String(), "keyContext", keyContext, "keyScope", mpseed,
+		"siteSalt.id", mpwIDBuf(buffer.Bytes()), "masterKey.id", mpwIDBuf(mk.key.Bytes()), "siteKey.id", mpwIDBuf(seed.Bytes()))
+
+	return seed, nil
+}
+
+// sitePassword encodes seed using the passwordType's templates or word list, passwordType and opts are expected
+// to have been validated.
+//
+//   The template types are adjusted to the policy if their password violates it, whereas the word list types
+//   can only be checked against it.
+func (mk *MasterKey) sitePassword(passwordType string, opts *PasswordOptions, seed []byte) (string, error) {
+	if opts == nil {
+		opts = &PasswordOptions{}
+	}
+
+	if wl, ok := passwordTypeWordLists[passwordType]; ok {
+		wf := opts.Words
+		if wf == nil {
+			wf = NewWordFormat(nil)
+		}
+		pw := wl.password(seed, wf)
+		if opts.Policy != nil {
+			if err := opts.Policy.Check(pw); err != nil {
+				return "", err
+			}
+		}
+		DbgL(debug.LevelInfo, "sitePassword", "passwordType", passwordType, "sitePassword", debug.Secret(pw))
+		return pw, nil
+	}
+
+	templates := passwordTypeTemplates[passwordType]
+	classes := func(class byte) string { return templateClass(passwordType, class) }
+	pw := mk.encodeTemplate(templates, classes, seed)
+
+	// only a violating password is re-encoded, thus compliant passwords remain unchanged
+	if opts.Policy != nil && opts.Policy.Check(pw) != nil {
+		var err error
+		if templates, classes, err = policyTemplates(passwordType, opts.Policy, mk.algorithmVersion, seed); err != nil {
+			return "", err
+		}
+		pw = mk.encodeTemplate(templates, classes, seed)
+	}
+	DbgL(debug.LevelInfo, "sitePassword", "passwordType", passwordType, "policy", opts.Policy, "sitePassword", debug.Secret(pw))
+
+	return pw, nil
+}
+
+// encodeTemplate encodes seed using one of templates, selected by the seed
+func (mk *MasterKey) encodeTemplate(templates [][]byte, classes func(byte) string, seed []byte) string {
+	av := mk.algorithmVersion
+	var temp = templates[av.seedByte(seed, 0)%len(templates)]
+
+	var buffer bytes.Buffer
+	for i, element := range temp {
+		passChars := classes(element)
+		passChar := passChars[av.seedByte(seed, i+1)%len(passChars)]
+		buffer.WriteByte(passChar)
+	}
+
+	return buffer.String()
+}
+
+// validateSiteParams validates the site parameters and returns the purpose's token
+func validateSiteParams(site string, counter uint32, purpose string) (PasswordPurpose, error) {
+	if err := ValidatePasswordPurpose(purpose); err != nil {
+		return PasswordPurposeUnSet, err
+	}
+	pp := ppmap[purpose]
+	if err := ValidateSite(site); err != nil {
+		return PasswordPurposeUnSet, err
+	}
+	if err := ValidateCounter(counter); err != nil {
+		return PasswordPurposeUnSet, err
+	}
+	if pp != PasswordPurposeAuthentication && counter > 1 {
+		return PasswordPurposeUnSet, ErrPasswordPurposeCounterOutOfRange
+	}
+
+	return pp, nil
+}
@@ -0,0 +1,151 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasterKeySitePassword(t *testing.T) {
+	expectations := []testVector{
+		{mpwseeds[0], 1, "long", "auth", "ZedaFaxcZaso9*"},
+		{mpwseeds[0], 2, "long", "auth", "Fovi2@JifpTupx"},
+		{mpwseeds[0], 1, "maximum", "auth", "pf4zS1LjCg&LjhsZ7T2~"},
+		{mpwseeds[0], 1, "pin", "auth", "6685"},
+		{mpwseeds[0], 1, "phrase", "auth", "ze juzxo sax taxocre"},
+	}
+
+	// scrypt only once for all of the site passwords
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint32(3), mk.AlgorithmVersion())
+
+	for _, tv := range expectations {
+		pw, err := mk.SitePassword(tv.pt, d.s, tv.c, tv.pp, "")
+		assert.NoError(t, err)
+		assert.Equal(t, tv.expect, pw)
+	}
+
+	pw, err := mk.SitePassword("long", d.s, 1, "rec", "mother's maiden name")
+	assert.NoError(t, err)
+	assert.Equal(t, "Deve4)DovtBojo", pw)
+
+	key, err := mk.SiteKey(d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Len(t, key, 32)
+}
+
+func TestMasterKeyBad(t *testing.T) {
+	_, err := crypto.NewMasterKey("", d.u, d.pw, 3)
+	assert.Equal(t, crypto.ErrMasterPasswordSeedEmpty, err)
+	_, err = crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 4)
+	assert.Equal(t, crypto.ErrAlgorithmVersionInvalid, err)
+	_, err = crypto.NewMasterKey(mpwseeds[0], "", d.pw, 3)
+	assert.Equal(t, crypto.ErrFullnameEmpty, err)
+	_, err = crypto.NewMasterKey(mpwseeds[0], d.u, "", 3)
+	assert.Equal(t, crypto.ErrPasswordEmpty, err)
+
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	expectations := []struct {
+		pt, s, pp string
+		c         uint32
+		err       error
+	}{
+		{"overdrive", d.s, "auth", 1, crypto.ErrPasswordTypeInvalid},
+		{"long", "", "auth", 1, crypto.ErrSiteEmpty},
+		{"long", d.s, "auth", 0, crypto.ErrCounter},
+		{"long", d.s, "", 1, crypto.ErrPasswordPurposeEmpty},
+		{"long", d.s, "noexist", 1, crypto.ErrPasswordPurposeInvalid},
+		{"long", d.s, "ident", 2, crypto.ErrPasswordPurposeCounterOutOfRange},
+	}
+	for _, e := range expectations {
+		_, err = mk.SitePassword(e.pt, e.s, e.c, e.pp, "")
+		assert.Equal(t, e.err, err)
+	}
+}
+
+func TestMasterKeyID(t *testing.T) {
+	const rlmKeyID = "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302"
+
+	mk, err := crypto.NewMasterKey(mpwseeds[0], "Robert Lee Mitchell", "banana colored duckling", 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, rlmKeyID, mk.ID())
+	assert.NoError(t, mk.VerifyID(""))
+	assert.NoError(t, mk.VerifyID(rlmKeyID))
+	assert.NoError(t, mk.VerifyID(strings.ToLower(rlmKeyID)))
+	assert.Equal(t, crypto.ErrMasterKeyIDMismatch, mk.VerifyID(strings.Repeat("0", 64)))
+
+	c := &config.MPConfig{
+		MasterPasswordSeed: mpwseeds[0],
+		PasswordType:       "long",
+		PasswordPurpose:    "auth",
+		Fullname:           "Robert Lee Mitchell",
+		Password:           "banana colored duckling",
+		Site:               "masterpasswordapp.com",
+		KeyID:              rlmKeyID,
+		Counter:            1,
+	}
+	pw, err := (&crypto.MasterPW{Config: c}).MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "Jejr5[RepuSosp", pw)
+
+	// mistyped master password, the password is still returned for warning purposes
+	c.Password = "banana colored ducklinq"
+	pw, err = (&crypto.MasterPW{Config: c}).MasterPassword()
+	assert.Equal(t, crypto.ErrMasterKeyIDMismatch, err)
+	assert.NotEmpty(t, pw)
+
+	mpw := &crypto.MasterPW{Config: c}
+	assert.Equal(t, crypto.ErrKeyIDInvalid, mpw.SetKeyID("98EEF4"))
+	c.KeyID = "98EEF4"
+	_, err = mpw.MasterPassword()
+	assert.Equal(t, crypto.ErrKeyIDInvalid, err)
+}
+
+func TestMasterKeyDestroy(t *testing.T) {
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, mpwseeds[0], mk.MasterPasswordSeed())
+	assert.NoError(t, mk.Mlock())
+
+	mk.Destroy()
+	_, err = mk.SitePassword("long", d.s, 1, "auth", "")
+	assert.Equal(t, crypto.ErrMasterKeyDestroyed, err)
+	assert.Equal(t, crypto.ErrMasterKeyDestroyed, mk.Mlock())
+
+	// idempotent
+	mk.Destroy()
+}
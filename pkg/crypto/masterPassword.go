@@ -21,10 +21,6 @@
 package crypto
 
 import (
-	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/binary"
 	"fmt"
 	"os"
 
@@ -32,7 +28,7 @@ import (
 	"github.com/TerraTech/go-MasterPassword/pkg/common"
 	"github.com/TerraTech/go-MasterPassword/pkg/config"
 	"github.com/TerraTech/go-MasterPassword/pkg/debug"
-	"golang.org/x/crypto/scrypt"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
 )
 
 // MpwSeries denotes the mpw cli client version compatibility.
@@ -46,17 +42,24 @@ const DefaultMasterPasswordSeed = common.DefaultMasterPasswordSeed
 var (
 	Dbg  = debug.NewDebug().Dbg
 	DbgO = debug.NewDebug().DbgO
+	// DbgL logs the structured records of the derivation steps (debug.LevelInfo)
+	DbgL = debug.NewDebug().Log
 )
 
 // MasterPW contains all relevant items for MasterPassword to act upon.
 type MasterPW struct {
 	Config             *config.MPConfig
 	masterPasswordSeed string
+	algorithmVersion   AlgorithmVersion
 	passwordPurpose    PasswordPurpose
 	passwordType       string
 	fullname           string
-	password           string
+	password           *Secret // see Destroy()
 	site               string
+	keyContext         string
+	keyID              string
+	wordFormat         *WordFormat
+	policy             *policy.Policy
 	counter            uint32
 }
 
@@ -67,119 +70,97 @@ func NewMasterPassword() *MasterPW {
 	}
 }
 
-// MasterPassword returns a derived password according to: http://masterpasswordapp.com/algorithm.html
-//
-//   Valid PasswordTypes: basic, long, maximum, medium, name, phrase, pin, short
-func (mpw *MasterPW) MasterPassword() (string, error) {
-	var err error
-
+// MasterKey returns the derived MasterKey after merging (and validating) Config ==> MasterPW
+func (mpw *MasterPW) MasterKey() (*MasterKey, error) {
 	// Fixup MasterPasswordSeed if ""
 	if mpw.masterPasswordSeed == "" && mpw.Config.MasterPasswordSeed == "" {
 		mpw.Config.MasterPasswordSeed = DefaultMasterPasswordSeed
 	}
 
 	// merge (and validate) Config ==> MasterPW
-	if err = mpw.MergeConfig(); err != nil {
-		return "", err
+	if err := mpw.MergeConfig(); err != nil {
+		return nil, err
 	}
 
-	// munge the master password seed depending on password purpose
-	mpseed := mpw.masterPasswordSeed + mpw.purpose()
-
-	// DUMP mpw
+	// DUMP mpw, with the master password redacted (see debug.SetRedaction())
 	if os.Getenv("MP_DUMP") != "" {
+		dump, c := *mpw, *mpw.Config
+		dump.password, c.Password = nil, debug.Redact(c.Password)
+		dump.Config = &c
 		fmt.Fprintf(os.Stderr, "\n== DUMP =======\n")
-		FQdebug.D(mpw)
+		FQdebug.D(&dump)
 		fmt.Fprintf(os.Stderr, "===============\n\n")
 	}
-	// FIXME: convert to template
-	//   Pro: cleans up the code and removes the Dbg() interstitials
-	//   Con: if something panics, might not have reached the template call
-	Dbg("-- mpw_masterKey (algorithm: 3)")
-	Dbg("fullName: %s", mpw.fullname)
-	Dbg("password: %s", mpw.password)
-	Dbg("masterPassword.id: %s", mpwIDBuf([]byte(mpw.password)))
-	Dbg("keyScope: %s", mpw.masterPasswordSeed)
-	Dbg("masterKeySalt: keyScope=%s | #fullName=%08X | fullName=%s", mpw.masterPasswordSeed, len(mpw.fullname), mpw.fullname)
-
-	templates := passwordTypeTemplates[mpw.passwordType]
-
-	var buffer bytes.Buffer
-	buffer.WriteString(mpw.masterPasswordSeed)
-	if err = binary.Write(&buffer, binary.BigEndian, uint32(len(mpw.fullname))); err != nil {
-		return "", err
-	}
-	buffer.WriteString(mpw.fullname)
 
-	salt := buffer.Bytes()
-	Dbg("  => masterKeySalt.id: %s", mpwIDBuf(salt))
+	return newMasterKey(mpw.algorithmVersion, mpw.masterPasswordSeed, mpw.fullname, mpw.password)
+}
 
-	key, err := scrypt.Key([]byte(mpw.password), salt, 32768, 8, 2, 64)
+// MasterPassword returns a derived password according to: http://masterpasswordapp.com/algorithm.html
+//
+//   Valid PasswordTypes: basic, bip39, diceware, long, maximum, medium, name, phrase, pin, short
+//
+//   NOTE: ErrMasterKeyIDMismatch is returned along with the derived password, allowing the caller to
+//         decide between refusing or warning.
+func (mpw *MasterPW) MasterPassword() (string, error) {
+	mk, err := mpw.MasterKey()
 	if err != nil {
-		return "", fmt.Errorf("failed to generate password: %s", err)
-	}
-	Dbg("masterKey: scrypt( masterPassword, masterKeySalt, N=32768, r=8, p=2, keyLen=64")
-	Dbg("  => masterKey.id: %s", mpwIDBuf(key))
-
-	Dbg("-- mpw_siteKey (algorithm: 3)")
-	Dbg("siteName: %s", mpw.site)
-	Dbg("siteCounter: %d", mpw.counter)
-	// FIXME: stringer doesn't appear to be working right
-	Dbg("keyPurpose: %d (%s)", mpw.passwordPurpose, mpw.passwordPurpose.String())
-	Dbg("keyContext: (null)") // not implemented
-	Dbg("keyScope: %s", mpseed)
-	Dbg("siteSalt: keyScope=%s | #siteName=%08X | siteName=%s | siteCounter=%08d | #keyContext=(null) | keyContext=(null)",
-		mpseed, len(mpw.site), mpw.site, mpw.counter)
-
-	// Danger Will Robinson, passwordPurpose comes into effect here, so caution with the Truncate()
-	buffer.Truncate(len(mpw.masterPasswordSeed))
-	buffer.WriteString(mpw.purpose()) // add the passwordPurpose suffix
-	if err = binary.Write(&buffer, binary.BigEndian, uint32(len(mpw.site))); err != nil {
-		return "", err
-	}
-	buffer.WriteString(mpw.site)
-	if err = binary.Write(&buffer, binary.BigEndian, mpw.counter); err != nil {
 		return "", err
 	}
-	Dbg("  => siteSalt.id: %s", mpwIDBuf(buffer.Bytes()))
+	defer mk.Destroy()
+
+	return mpw.SitePassword(mk)
+}
 
-	Dbg("siteKey: hmac-sha256( masterKey.id=%s, siteSalt )", mpwIDBuf(key))
-	var hmacv = hmac.New(sha256.New, key)
-	if _, err = hmacv.Write(buffer.Bytes()); err != nil {
+// SitePassword returns the site password derived from mk, for callers also needing the MasterKey.
+//
+//   NOTE: mk is expected to come from MasterKey(), which merges the Config
+//   NOTE: ErrMasterKeyIDMismatch is returned along with the derived password, see MasterPassword()
+func (mpw *MasterPW) SitePassword(mk *MasterKey) (string, error) {
+	seed, err := mk.siteKey(mpw.site, mpw.counter, mpw.passwordPurpose, mpw.keyContext)
+	if err != nil {
 		return "", err
 	}
-	var seed = hmacv.Sum(nil)
-	Dbg("  => siteKey.id: %s", mpwIDBuf(seed))
-
-	var temp = templates[int(seed[0])%len(templates)]
-
-	buffer.Truncate(0)
-	for i, element := range temp {
-		passChars := templateCharacters[element]
-		passChar := passChars[int(seed[i+1])%len(passChars)]
-		buffer.WriteByte(passChar)
+	defer seed.Close()
+	pw, err := mk.sitePassword(mpw.passwordType, &PasswordOptions{Words: mpw.wordFormat, Policy: mpw.policy}, seed.Bytes())
+	if err != nil {
+		return "", err
 	}
 
-	return buffer.String(), nil
+	return pw, mk.VerifyID(mpw.keyID)
 }
 
 // MasterPassword returns a derived password according to: http://masterpasswordapp.com/algorithm.html
 //
-//   Valid PasswordTypes: basic, long, maximum, medium, name, phrase, pin, short
+//   Valid PasswordTypes: basic, bip39, diceware, long, maximum, medium, name, phrase, pin, short
 func MasterPassword(mpwseed, passwordType, passwordPurpose, fullname, password, site string, counter uint32) (string, error) {
 	mpw := &MasterPW{
 		Config:             &config.MPConfig{},
 		masterPasswordSeed: mpwseed,
 		passwordType:       passwordType,
 		fullname:           fullname,
-		password:           password,
 		site:               site,
 		counter:            counter,
 	}
+	defer mpw.Destroy()
 	// needs to be set via method for validation
 	if err := mpw.SetPasswordPurpose(passwordPurpose); err != nil {
 		return "", err
 	}
+	if err := mpw.SetPassword(password); err != nil {
+		return "", err
+	}
 
 	return mpw.MasterPassword()
 }
+
+// Destroy zeroes the master password, so long-lived callers (agents, servers) can wipe their state deterministically.
+//
+//   NOTE: Config.Password is merely cleared, as Go strings can't be wiped
+//   NOTE: MasterKeys returned by MasterKey() are the caller's to Destroy()
+func (mpw *MasterPW) Destroy() {
+	_ = mpw.password.Close()
+	mpw.password = nil
+	if mpw.Config != nil {
+		mpw.Config.Password = ""
+	}
+}
@@ -246,3 +246,187 @@ func TestMasterPasswordPurposeBad(t *testing.T) {
 		}
 	}
 }
+
+func TestMasterPasswordKeyContext(t *testing.T) {
+	expectations := []struct {
+		tv testVector
+		kc string
+	}{
+		// no keyContext must not perturb the siteSalt
+		{testVector{mpwseeds[0], 1, "long", "auth", "ZedaFaxcZaso9*"}, ""},
+		{testVector{mpwseeds[0], 1, "phrase", "rec", "devl moz tocjode qan"}, "mother's maiden name"},
+		{testVector{mpwseeds[0], 1, "long", "rec", "Deve4)DovtBojo"}, "mother's maiden name"},
+		{testVector{mpwseeds[0], 1, "phrase", "rec", "mis jiqvohoju yawa"}, "first pet"},
+		{testVector{mpwseeds[0], 1, "long", "rec", "MishJiqv3(Jibo"}, "first pet"},
+		{testVector{mpwseeds[0], 1, "name", "ident", "cemdijepe"}, "login"},
+	}
+
+	for _, e := range expectations {
+		c := newMpConfig(e.tv)
+		c.KeyContext = e.kc
+		mpw := &crypto.MasterPW{Config: c}
+		pw, err := mpw.MasterPassword()
+		assert.NoError(t, err)
+		assert.Equal(t, e.tv.expect, pw)
+
+		// setter path
+		mpw, err = newMpw(e.tv)
+		assert.NoError(t, err)
+		assert.NoError(t, mpw.SetKeyContext(e.kc))
+		pw, err = mpw.MasterPassword()
+		assert.NoError(t, err)
+		assert.Equal(t, e.tv.expect, pw)
+	}
+}
+
+// TestMasterPasswordAlgorithmVersions tests the historic v0-v2 quirks against v3.
+//
+//   v0: 16bit seed bytes, character lengths
+//   v1: character lengths (fullname, siteName, keyContext)
+//   v2: character length (fullname)
+func TestMasterPasswordAlgorithmVersions(t *testing.T) {
+	type avVector struct {
+		u, s, kc string
+		tv       testVector
+	}
+	const (
+		rlm = "Robert Lee Mitchell"
+		mpa = "masterpasswordapp.com"
+		mb  = "⛄"
+	)
+
+	expectations := [][]avVector{
+		{
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "Feji5@ReduWosh"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "maximum", "auth", "w1!3bA3icmRAc)SS@lwl"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "pin", "auth", "2117"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "name", "ident", "lozwajave"}},
+			{rlm, mb, "", testVector{mpwseeds[0], 1, "long", "auth", "HahiVana2@Nole"}},
+			{mb, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "HajrYudo7@Mamh"}},
+			{rlm, mpa, mb + " question", testVector{mpwseeds[0], 1, "phrase", "rec", "fac hesxisayu ziri"}},
+			// siteKey[0] >= 0x80, sign extended for the template lookup as well
+			{rlm, "site2.example.com", "", testVector{mpwseeds[0], 1, "long", "auth", "LupbFoxp0_Bene"}},
+			{rlm, "site2.example.com", "", testVector{mpwseeds[0], 1, "maximum", "auth", "Nv)wH4l)mcl3m)bmow1'"}},
+			{rlm, "site4.example.com", "", testVector{mpwseeds[0], 1, "long", "auth", "TuxoVepm3!Zavi"}},
+			{rlm, "site4.example.com", "", testVector{mpwseeds[0], 1, "maximum", "auth", "HcHl3m4@)HcimJovcH9."}},
+		},
+		{
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "Jejr5[RepuSosp"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "maximum", "auth", "W6@692^B1#&@gVdSdLZ@"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "pin", "auth", "7662"}},
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "name", "ident", "wohzaqage"}},
+			{rlm, mb, "", testVector{mpwseeds[0], 1, "long", "auth", "WawiYarp2@Kodh"}},
+			{mb, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "WaqoGuho2[Xaxw"}},
+			{rlm, mpa, mb + " question", testVector{mpwseeds[0], 1, "phrase", "rec", "jav wesmilagu ziri"}},
+		},
+		{
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "Jejr5[RepuSosp"}},
+			{rlm, mb, "", testVector{mpwseeds[0], 1, "long", "auth", "LiheCuwhSerz6)"}},
+			{mb, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "WaqoGuho2[Xaxw"}},
+			{rlm, mpa, mb + " question", testVector{mpwseeds[0], 1, "phrase", "rec", "qahn ham lendoka duq"}},
+		},
+		{
+			{rlm, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "Jejr5[RepuSosp"}},
+			{rlm, mb, "", testVector{mpwseeds[0], 1, "long", "auth", "LiheCuwhSerz6)"}},
+			{mb, mpa, "", testVector{mpwseeds[0], 1, "long", "auth", "NopaDajh8=Fene"}},
+			{rlm, mpa, mb + " question", testVector{mpwseeds[0], 1, "phrase", "rec", "qahn ham lendoka duq"}},
+		},
+	}
+
+	for version, avvs := range expectations {
+		for _, avv := range avvs {
+			c := newMpConfig(avv.tv)
+			c.Fullname = avv.u
+			c.Password = "banana colored duckling"
+			c.Site = avv.s
+			c.KeyContext = avv.kc
+			mpw := &crypto.MasterPW{Config: c}
+			assert.NoError(t, mpw.SetAlgorithmVersion(uint32(version)))
+			pw, err := mpw.MasterPassword()
+			assert.NoError(t, err)
+			assert.Equal(t, avv.tv.expect, pw, "algorithm version: %d", version)
+		}
+	}
+
+	// config path, v3 is used when unset
+	tv := testVector{mpwseeds[0], 1, "long", "auth", "HajrYudo7@Mamh"}
+	c := newMpConfig(tv)
+	c.Fullname = mb
+	c.Password = "banana colored duckling"
+	c.Site = mpa
+	var v0 uint32
+	c.AlgorithmVersion = &v0
+	pw, err := (&crypto.MasterPW{Config: c}).MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, tv.expect, pw)
+
+	c.AlgorithmVersion = nil
+	pw, err = (&crypto.MasterPW{Config: c}).MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "NopaDajh8=Fene", pw)
+}
+
+func TestAlgorithmVersionBad(t *testing.T) {
+	mpw := crypto.NewMasterPassword()
+	assert.Equal(t, crypto.ErrAlgorithmVersionInvalid, mpw.SetAlgorithmVersion(4))
+
+	var v uint32 = 69
+	c := newMpConfig(testVector{mpwseeds[0], 1, "long", "auth", ""})
+	c.AlgorithmVersion = &v
+	_, err := (&crypto.MasterPW{Config: c}).MasterPassword()
+	assert.Equal(t, crypto.ErrAlgorithmVersionInvalid, err)
+}
+
+func TestMasterPasswordSitePassword(t *testing.T) {
+	c := &config.MPConfig{
+		MasterPasswordSeed: mpwseeds[0],
+		PasswordType:       "l",
+		PasswordPurpose:    "a",
+		Fullname:           "Robert Lee Mitchell",
+		Password:           "banana colored duckling",
+		Site:               "masterpasswordapp.com",
+		Counter:            1,
+	}
+	mpw := &crypto.MasterPW{Config: c}
+	mk, err := mpw.MasterKey()
+	if !assert.NoError(t, err) {
+		return
+	}
+	pw, err := mpw.SitePassword(mk)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jejr5[RepuSosp", pw)
+
+	assert.Equal(t, "long", crypto.PasswordTypeName(c.PasswordType))
+	assert.Equal(t, "maximum", crypto.PasswordTypeName("maximum"))
+	token, err := crypto.PasswordPurposeToToken(c.PasswordPurpose)
+	assert.NoError(t, err)
+	assert.Equal(t, "auth", token.Name())
+}
+
+func TestMasterPasswordDestroy(t *testing.T) {
+	c := &config.MPConfig{
+		MasterPasswordSeed: mpwseeds[0],
+		PasswordType:       "l",
+		PasswordPurpose:    "a",
+		Fullname:           "Robert Lee Mitchell",
+		Password:           "banana colored duckling",
+		Site:               "masterpasswordapp.com",
+		Counter:            1,
+	}
+	mpw := &crypto.MasterPW{Config: c}
+	pw, err := mpw.MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "Jejr5[RepuSosp", pw)
+
+	mpw.Destroy()
+	assert.Equal(t, "", c.Password)
+	_, err = mpw.MasterPassword()
+	assert.Equal(t, crypto.ErrPasswordEmpty, err)
+
+	// idempotent, and usable again once given a password
+	mpw.Destroy()
+	assert.NoError(t, mpw.SetPassword("banana colored duckling"))
+	pw, err = mpw.MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "Jejr5[RepuSosp", pw)
+}
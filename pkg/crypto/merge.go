@@ -34,6 +34,15 @@ func (mpw *MasterPW) MergeConfigEX(c *config.MPConfig) error {
 	if mpw.masterPasswordSeed == "" {
 		mpw.masterPasswordSeed = c.MasterPasswordSeed
 	}
+	if mpw.algorithmVersion == AlgorithmVersionUnSet {
+		var version = AlgorithmVersionCurrent.Version()
+		if c.AlgorithmVersion != nil {
+			version = *c.AlgorithmVersion
+		}
+		if err := mpw.SetAlgorithmVersion(version); err != nil {
+			return err
+		}
+	}
 	if mpw.passwordPurpose == PasswordPurposeUnSet {
 		if err := mpw.SetPasswordPurpose(c.PasswordPurpose); err != nil {
 			return err
@@ -45,12 +54,26 @@ func (mpw *MasterPW) MergeConfigEX(c *config.MPConfig) error {
 	if mpw.fullname == "" {
 		mpw.fullname = c.Fullname
 	}
-	if mpw.password == "" {
-		mpw.password = c.Password
+	if mpw.password == nil && c.Password != "" {
+		if err := mpw.SetPassword(c.Password); err != nil {
+			return err
+		}
 	}
 	if mpw.site == "" {
 		mpw.site = c.Site
 	}
+	if mpw.keyContext == "" {
+		mpw.keyContext = c.KeyContext
+	}
+	if mpw.keyID == "" {
+		mpw.keyID = c.KeyID
+	}
+	if mpw.wordFormat == nil {
+		mpw.wordFormat = NewWordFormat(c.Words)
+	}
+	if mpw.policy == nil {
+		mpw.policy = c.Policy
+	}
 	if mpw.counter == 0 {
 		mpw.counter = c.Counter
 	}
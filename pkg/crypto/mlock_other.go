@@ -0,0 +1,43 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package crypto
+
+// mlock is a no-op on platforms without mlock(2)
+func mlock(buf []byte) error {
+	return nil
+}
+
+// munlock is a no-op on platforms without munlock(2)
+func munlock(buf []byte) error {
+	return nil
+}
+
+// mmapGuarded falls back to the heap on platforms without mmap(2), i.e. without guard pages
+func mmapGuarded(size int) (uintptr, []byte, error) {
+	return 0, make([]byte, size), nil
+}
+
+// munmapGuarded is a no-op on platforms without mmap(2)
+func munmapGuarded(addr uintptr) error {
+	return nil
+}
@@ -0,0 +1,90 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// +build darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package crypto
+
+import (
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// guarded are the mappings of mmapGuarded() by their address, as unix.Munmap() requires the original slice.
+// Secret only records the address, so dumping it (e.g. FQdebug.D) never touches the guard pages.
+var guarded = struct {
+	sync.Mutex
+	m map[uintptr][]byte
+}{m: make(map[uintptr][]byte)}
+
+// mlock prevents buf from being paged out to swap
+func mlock(buf []byte) error {
+	return unix.Mlock(buf)
+}
+
+// munlock undoes mlock
+func munlock(buf []byte) error {
+	return unix.Munlock(buf)
+}
+
+// mmapGuarded maps size bytes between two PROT_NONE guard pages, returning the mapping's address and the data,
+// which ends at the trailing guard page so that overruns fault.
+func mmapGuarded(size int) (uintptr, []byte, error) {
+	page := unix.Getpagesize()
+	pages := (size + page - 1) / page
+	if pages == 0 {
+		pages = 1
+	}
+
+	mem, err := unix.Mmap(-1, 0, (pages+2)*page, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return 0, nil, err
+	}
+	end := (pages + 1) * page
+	if err = unix.Mprotect(mem[:page], unix.PROT_NONE); err == nil {
+		err = unix.Mprotect(mem[end:], unix.PROT_NONE)
+	}
+	if err != nil {
+		_ = unix.Munmap(mem)
+		return 0, nil, err
+	}
+
+	addr := uintptr(unsafe.Pointer(&mem[0]))
+	guarded.Lock()
+	guarded.m[addr] = mem
+	guarded.Unlock()
+
+	return addr, mem[end-size : end : end], nil
+}
+
+// munmapGuarded unmaps the mapping of mmapGuarded() at addr
+func munmapGuarded(addr uintptr) error {
+	guarded.Lock()
+	mem, ok := guarded.m[addr]
+	delete(guarded.m, addr)
+	guarded.Unlock()
+	if !ok {
+		return nil
+	}
+
+	return unix.Munmap(mem)
+}
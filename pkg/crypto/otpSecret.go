@@ -0,0 +1,67 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+// OTPSecretLength is the length of the derived OTP shared secret, as recommended by RFC 4226 (160 bits)
+const OTPSecretLength = 20
+
+// OTPSecret returns the RFC 4226/6238 shared secret derived for the given site parameters.
+//
+// The site key is derived using a dedicated key scope (".otp"), thus the secret never
+// shares its seed with any of the site's passwords.
+//
+//   NOTE: keyContext may be "", which will leave the siteSalt unperturbed
+func (mk *MasterKey) OTPSecret(site string, counter uint32, keyContext string) ([]byte, error) {
+	if err := ValidateSite(site); err != nil {
+		return nil, err
+	}
+	if err := ValidateCounter(counter); err != nil {
+		return nil, err
+	}
+
+	return mk.otpSecret(site, counter, keyContext)
+}
+
+// OTPSecret returns the OTP shared secret derived from mk, see SitePassword()
+//
+//   NOTE: the password type and purpose are ignored
+//   NOTE: ErrMasterKeyIDMismatch is returned along with the derived secret, see MasterPassword()
+func (mpw *MasterPW) OTPSecret(mk *MasterKey) ([]byte, error) {
+	secret, err := mk.otpSecret(mpw.site, mpw.counter, mpw.keyContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return secret, mk.VerifyID(mpw.keyID)
+}
+
+// otpSecret derives the secret, all params are expected to have been validated
+func (mk *MasterKey) otpSecret(site string, counter uint32, keyContext string) ([]byte, error) {
+	seed, err := mk.siteKey(site, counter, passwordPurposeOTP, keyContext)
+	if err != nil {
+		return nil, err
+	}
+	defer seed.Close()
+	secret := make([]byte, OTPSecretLength)
+	copy(secret, seed.Bytes())
+
+	return secret, nil
+}
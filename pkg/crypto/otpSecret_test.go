@@ -0,0 +1,59 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasterKeyOTPSecret(t *testing.T) {
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	secret, err := mk.OTPSecret(d.s, 1, "")
+	assert.NoError(t, err)
+	assert.Len(t, secret, crypto.OTPSecretLength)
+	assert.Equal(t, "1B3D41420F02E4ADF6FAB809FAE188C99A958650", fmt.Sprintf("%X", secret))
+
+	// never the same as the site key
+	key, err := mk.SiteKey(d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, key[:crypto.OTPSecretLength], secret)
+
+	// perturbed by counter and context
+	other, err := mk.OTPSecret(d.s, 2, "")
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+	other, err = mk.OTPSecret(d.s, 1, "vpn")
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, err = mk.OTPSecret("", 1, "")
+	assert.Equal(t, crypto.ErrSiteEmpty, err)
+	_, err = mk.OTPSecret(d.s, 0, "")
+	assert.Equal(t, crypto.ErrCounter, err)
+}
@@ -0,0 +1,188 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
+)
+
+// password policy exported errors
+var (
+	ErrPolicyTooLong = errors.New("Policy minLength exceeds the site key's 31 characters")
+)
+
+// PasswordOptions are the optional parameters of SitePasswordEX(), nil fields use the defaults
+type PasswordOptions struct {
+	Words  *WordFormat    `json:"words,omitempty"`
+	Policy *policy.Policy `json:"policy,omitempty"`
+}
+
+// NewPasswordOptions returns the PasswordOptions configured by c
+func NewPasswordOptions(c *config.MPConfig) *PasswordOptions {
+	return &PasswordOptions{
+		Words:  NewWordFormat(c.Words),
+		Policy: c.Policy,
+	}
+}
+
+// Validate checks opts against passwordType, which is expected to have been validated
+func (opts *PasswordOptions) Validate(passwordType string) error {
+	if opts.Words != nil {
+		if err := opts.Words.Validate(passwordType); err != nil {
+			return err
+		}
+	}
+	if opts.Policy != nil {
+		return opts.Policy.Validate()
+	}
+
+	return nil
+}
+
+// CheckPasswordType reports the first policy violation that any password of passwordType may have, if any.
+//
+//   NOTE: SitePasswordEX() adjusts the violating template type passwords to the policy, see policyTemplates()
+func CheckPasswordType(passwordType string, wf *WordFormat, p *policy.Policy) error {
+	if err := ValidatePasswordType(passwordType); err != nil {
+		return err
+	}
+	passwordType = PasswordTypeName(passwordType)
+
+	if wl, ok := passwordTypeWordLists[passwordType]; ok {
+		if wf == nil {
+			wf = NewWordFormat(nil)
+		}
+		return p.CheckPattern(wl.pattern(wf))
+	}
+
+	classes := func(class byte) string { return templateClass(passwordType, class) }
+	for _, template := range passwordTypeTemplates[passwordType] {
+		if err := p.CheckPattern(templatePattern(template, classes)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// templatePattern describes the passwords of template, nil if any of its classes is empty
+func templatePattern(template []byte, classes func(byte) string) *policy.Pattern {
+	pt := &policy.Pattern{MinLength: len(template), MaxLength: len(template)}
+
+	var chars bytes.Buffer
+	for _, element := range template {
+		passChars := classes(element)
+		if passChars == "" {
+			return nil
+		}
+		chars.WriteString(passChars)
+		if class := policy.ClassOf(passChars); class != "" {
+			pt.Guarantees = append(pt.Guarantees, class)
+		}
+	}
+	pt.Chars = chars.String()
+
+	return pt
+}
+
+// policyTemplates returns the templates and character classes of passwordType adjusted to p, which is
+// expected to have been validated. It is only used once the unadjusted password violates p.
+//
+//  1. some templates comply, once the forbidden characters are removed from their classes: only those
+//  2. none comply: a single template synthesized from the site key, see synthesizeTemplate()
+func policyTemplates(passwordType string, p *policy.Policy, av AlgorithmVersion, seed []byte) ([][]byte, func(byte) string, error) {
+	templates := passwordTypeTemplates[passwordType]
+	classes := func(class byte) string { return templateClass(passwordType, class) }
+
+	allowed := func(class byte) string { return p.Allowed(classes(class)) }
+	var compliant [][]byte
+	for _, template := range templates {
+		if pt := templatePattern(template, allowed); pt != nil && p.CheckPattern(pt) == nil {
+			compliant = append(compliant, template)
+		}
+	}
+	if len(compliant) != 0 {
+		return compliant, allowed, nil
+	}
+
+	base := templates[av.seedByte(seed, 0)%len(templates)]
+	template, synthesized, err := synthesizeTemplate(base, classes, p, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return [][]byte{template}, synthesized, nil
+}
+
+// synthesizeTemplate returns a template of a single class, made up of the allowed characters of base,
+// with each of p's required classes placed at a position selected by the site key.
+//
+// base is the template that would otherwise have been used, thus a pin remains numeric, and its
+// length is clamped to p's length limits.
+func synthesizeTemplate(base []byte, classes func(byte) string, p *policy.Policy, seed []byte) ([]byte, func(byte) string, error) {
+	length := len(base)
+	if p.MinLength != 0 && length < int(p.MinLength) {
+		length = int(p.MinLength)
+	}
+	if p.MaxLength != 0 && length > int(p.MaxLength) {
+		length = int(p.MaxLength)
+	}
+	if length > maxTemplateLength {
+		return nil, nil, ErrPolicyTooLong
+	}
+	if length < len(p.Require) {
+		return nil, nil, policy.ErrUnsatisfiable
+	}
+
+	// the classes are local to this template, '0'... are the required classes
+	var filler []byte
+	for _, element := range base {
+		for _, c := range []byte(p.Allowed(classes(element))) {
+			if bytes.IndexByte(filler, c) < 0 {
+				filler = append(filler, c)
+			}
+		}
+	}
+	if len(filler) == 0 {
+		return nil, nil, policy.ErrUnsatisfiable
+	}
+	chars := map[byte]string{'x': string(filler)}
+
+	template := bytes.Repeat([]byte{'x'}, length)
+	free := make([]int, length)
+	for i := range free {
+		free[i] = i
+	}
+	// placed using the trailing seed bytes, as the leading ones select the characters
+	for i, class := range p.Require {
+		element := byte('0' + i)
+		chars[element] = p.Allowed(policy.Classes[class])
+		j := int(seed[len(seed)-1-i]) % len(free)
+		template[free[j]] = element
+		free = append(free[:j], free[j+1:]...)
+	}
+
+	return template, func(class byte) string { return chars[class] }, nil
+}
@@ -0,0 +1,147 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPasswordType(t *testing.T) {
+	expectations := []struct {
+		pt   string
+		spec string
+		err  error
+	}{
+		{"long", "", nil},
+		{"long", "min=12,max=16,require=upper+lower+digit+symbol", nil},
+		{"long", "max=12", policy.ErrTooLong},
+		{"long", "forbid=';", policy.ErrForbidden},
+		{"maximum", "require=digit+symbol", nil},
+		{"basic", "require=symbol", policy.ErrClassMissing},
+		{"pin", "require=digit", nil},
+		{"pin", "min=6", policy.ErrTooShort},
+		{"i", "min=6", policy.ErrTooShort},
+		{"phrase", "require=upper", policy.ErrClassMissing},
+		{"diceware", "min=20,require=lower", nil},
+		{"diceware", "require=upper", policy.ErrClassMissing},
+		{"diceware", "max=30", policy.ErrTooLong},
+		{"bip39", "forbid= ", policy.ErrForbidden},
+	}
+
+	for _, tv := range expectations {
+		p, err := policy.Parse(tv.spec)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, tv.err, crypto.CheckPasswordType(tv.pt, nil, p), "%s %s", tv.pt, tv.spec)
+	}
+
+	title := &crypto.WordFormat{Separator: "-", Case: "title"}
+	assert.NoError(t, crypto.CheckPasswordType("diceware", title, &policy.Policy{Require: []string{"upper", "lower", "symbol"}}))
+	assert.Equal(t, crypto.ErrPasswordTypeInvalid, crypto.CheckPasswordType("noexist", nil, &policy.Policy{}))
+}
+
+func TestMasterKeySitePasswordPolicy(t *testing.T) {
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	sitePassword := func(pt, spec string, counter uint32) (string, error) {
+		p, err := policy.Parse(spec)
+		if err != nil {
+			return "", err
+		}
+		return mk.SitePasswordEX(pt, &crypto.PasswordOptions{Policy: p}, d.s, counter, "auth", "")
+	}
+
+	// compliant types are unchanged
+	pw, err := sitePassword("long", "min=12,require=digit+symbol", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "ZedaFaxcZaso9*", pw)
+
+	expectations := []struct {
+		pt, spec string
+		expect   string
+	}{
+		{"long", "forbid=*'", "ZedaFaxcZaso9!"},
+		{"long", "max=10,require=digit", "6u0koxXNDm"},
+		{"pin", "min=6", "668545"},
+		{"phrase", "require=upper+digit,forbid= ", "6doroaxyhmKxhnyoarzg"},
+		{"basic", "min=12,max=12,require=symbol+upper", "QrUuSV=aPgJL"},
+	}
+	for _, tv := range expectations {
+		pw, err = sitePassword(tv.pt, tv.spec, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, tv.expect, pw, "%s %s", tv.pt, tv.spec)
+
+		// always compliant and deterministic
+		p, _ := policy.Parse(tv.spec)
+		for counter := uint32(1); counter <= 100; counter++ {
+			pw, err = sitePassword(tv.pt, tv.spec, counter)
+			assert.NoError(t, err)
+			assert.NoError(t, p.Check(pw), "%s %s %q", tv.pt, tv.spec, pw)
+			again, _ := sitePassword(tv.pt, tv.spec, counter)
+			assert.Equal(t, pw, again)
+		}
+	}
+
+	// already compliant passwords are unchanged, even though the type's templates may violate the policy
+	p := &policy.Policy{Forbid: "'"}
+	adjusted := 0
+	for counter := uint32(1); counter <= 100; counter++ {
+		plain, err := mk.SitePasswordEX("maximum", nil, d.s, counter, "auth", "")
+		assert.NoError(t, err)
+		pw, err = mk.SitePasswordEX("maximum", &crypto.PasswordOptions{Policy: p}, d.s, counter, "auth", "")
+		assert.NoError(t, err)
+		assert.NoError(t, p.Check(pw), "%q", pw)
+		if p.Check(plain) == nil {
+			assert.Equal(t, plain, pw)
+		} else {
+			adjusted++
+		}
+	}
+	assert.NotZero(t, adjusted)
+
+	// a synthesized pin remains numeric
+	pw, err = sitePassword("pin", "min=6", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", strings.Trim(pw, "0123456789"))
+
+	// the word list types are only checked
+	_, err = sitePassword("diceware", "max=10", 1)
+	assert.Equal(t, policy.ErrTooLong, err)
+
+	_, err = sitePassword("pin", "max=40,min=32", 1)
+	assert.Equal(t, crypto.ErrPolicyTooLong, err)
+	_, err = sitePassword("long", "min=40", 1)
+	assert.Equal(t, crypto.ErrPolicyTooLong, err)
+	_, err = sitePassword("pin", "max=2,require=digit+lower+upper", 1)
+	assert.Equal(t, policy.ErrUnsatisfiable, err)
+	_, err = sitePassword("pin", "require=upper", 1)
+	assert.NoError(t, err)
+	_, err = sitePassword("long", "require=digit,forbid=0123456789", 1)
+	assert.Equal(t, policy.ErrUnsatisfiable, err)
+}
@@ -30,6 +30,11 @@ const (
 	PasswordPurposeAuthentication
 	PasswordPurposeIdentification
 	PasswordPurposeRecovery
+
+	// passwordPurposeSSHKey and passwordPurposeOTP are reserved for SSHKey() and OTPSecret(),
+	// thus deliberately absent from ppmap
+	passwordPurposeSSHKey
+	passwordPurposeOTP
 )
 
 // PasswordPurpose exported errors
@@ -77,11 +82,20 @@ func (pp *PasswordPurpose) String() string {
 		return "Identification"
 	case PasswordPurposeRecovery:
 		return "Recovery"
+	case passwordPurposeSSHKey:
+		return "SSH Key"
+	case passwordPurposeOTP:
+		return "OTP"
 	}
 
 	return ""
 }
 
+// Name returns the purpose's canonical name: auth, ident or rec
+func (pp *PasswordPurpose) Name() string {
+	return pampp[*pp]
+}
+
 // SetPasswordPurpose sets the MasterPassword's generated password purpose
 func (mpw *MasterPW) SetPasswordPurpose(purpose string) (err error) {
 	if err = ValidatePasswordPurpose(purpose); err == nil {
@@ -126,15 +140,19 @@ func ValidatePasswordPurpose(purpose string) error {
 	return nil
 }
 
-// purpose returns the string used to munge MasterPassword's seed
-func (mpw *MasterPW) purpose() string {
-	switch mpw.passwordPurpose {
+// scope returns the string used to munge MasterPassword's seed
+func (pp *PasswordPurpose) scope() string {
+	switch *pp {
 	case PasswordPurposeAuthentication:
 		return ""
 	case PasswordPurposeIdentification:
 		return ".login"
 	case PasswordPurposeRecovery:
 		return ".answer"
+	case passwordPurposeSSHKey:
+		return ".ssh"
+	case passwordPurposeOTP:
+		return ".otp"
 	}
 
 	panic(ErrPasswordPurposeInvalid.Error())
@@ -21,29 +21,51 @@
 package crypto
 
 import (
+	"errors"
 	"sort"
+	"strings"
+	"unicode"
 )
 
 func init() {
 	var ptt = passwordTypeTemplates
 
 	// add shortcodes
-	ptt["b"] = ptt["basic"]
-	ptt["l"] = ptt["long"]
-	ptt["x"] = ptt["maximum"]
-	ptt["m"] = ptt["medium"]
-	ptt["n"] = ptt["name"]
-	ptt["p"] = ptt["phrase"]
-	ptt["i"] = ptt["pin"]
-	ptt["s"] = ptt["short"]
+	for shortcode, name := range passwordTypeShortcodes {
+		ptt[shortcode] = ptt[name]
+	}
 }
 
+// RegisterPasswordType exported errors
+var (
+	ErrCharacterClassInvalid = errors.New("Character class must be a single character mapped to printable ASCII characters")
+	ErrPasswordTypeExists    = errors.New("Password type is already registered")
+	ErrPasswordTypeName      = errors.New("Password type name must be set and not contain whitespace")
+	ErrTemplateClassUnknown  = errors.New("Password template uses an undefined character class")
+	ErrTemplateEmpty         = errors.New("Password type requires at least one template")
+	ErrTemplateTooLong       = errors.New("Password template is longer than the site key allows (31)")
+)
+
+// maxTemplateLength is limited by the site key, as its first byte selects the template
+const maxTemplateLength = 32 - 1
+
 // MasterPasswordTypes is for listing the current supported password types.
 //
 //   Default: long
-const MasterPasswordTypes = "basic, long, maximum, medium, name, phrase, pin, short"
+const MasterPasswordTypes = "basic, bip39, diceware, long, maximum, medium, name, phrase, pin, short"
 
 var (
+	passwordTypeShortcodes = map[string]string{
+		"b": "basic",
+		"l": "long",
+		"x": "maximum",
+		"m": "medium",
+		"n": "name",
+		"p": "phrase",
+		"i": "pin",
+		"s": "short",
+	}
+
 	passwordTypeTemplates = map[string][][]byte{
 		"basic": {[]byte("aaanaaan"), []byte("aannaaan"), []byte("aaannaaa")},
 		"long": {[]byte("CvcvnoCvcvCvcv"), []byte("CvcvCvcvnoCvcv"), []byte("CvcvCvcvCvcvno"), []byte("CvccnoCvcvCvcv"), []byte("CvccCvcvnoCvcv"),
@@ -59,6 +81,10 @@ var (
 		"short":   {[]byte("Cvcn")},
 	}
 
+	// passwordTypeCharacters holds the character classes of the registered password types,
+	// which take precedence over templateCharacters
+	passwordTypeCharacters = map[string]map[byte]string{}
+
 	templateCharacters = map[byte]string{
 		'V': "AEIOU",
 		'C': "BCDFGHJKLMNPQRSTVWXYZ",
@@ -73,16 +99,98 @@ var (
 	}
 )
 
+// PasswordTypeName returns the canonical name of passwordType, resolving its shortcode
+func PasswordTypeName(passwordType string) string {
+	if name, ok := passwordTypeShortcodes[passwordType]; ok {
+		return name
+	}
+
+	return passwordType
+}
+
 // GetPasswordTypes returns a sorted list of valid password types
 func (m *MasterPW) GetPasswordTypes() []string {
-	keys := make([]string, len(passwordTypeTemplates))
-	i := 0
+	keys := make([]string, 0, len(passwordTypeTemplates)+len(passwordTypeWordLists))
 	for k := range passwordTypeTemplates {
-		keys[i] = k
-		i++
+		keys = append(keys, k)
+	}
+	for k := range passwordTypeWordLists {
+		keys = append(keys, k)
 	}
 
 	sort.Strings(keys)
 
 	return keys
 }
+
+// RegisterPasswordType adds a custom template based password type, e.g. from gompw.toml's [types."name"] tables.
+//
+// Each template character selects a character class, either one of the built-in classes (V, C, v, c, A, a, n,
+// o, x and ' ') or one of the given classes, which take precedence.
+//
+//   templates: ["CvcvnnCvcvyy"]
+//   classes:   {"y": "@&%?,=[]_:-+*$#!^~()/."}
+//
+//   NOTE: not safe for concurrent use, register the password types before deriving any passwords
+func RegisterPasswordType(name string, templates []string, classes map[string]string) error {
+	if name == "" || strings.IndexFunc(name, unicode.IsSpace) >= 0 {
+		return ErrPasswordTypeName
+	}
+	if err := ValidatePasswordType(name); err == nil {
+		return ErrPasswordTypeExists
+	}
+	if len(templates) == 0 {
+		return ErrTemplateEmpty
+	}
+
+	characters := make(map[byte]string, len(classes))
+	for class, chars := range classes {
+		if len(class) != 1 || !isPrintableASCII(class) || chars == "" || !isPrintableASCII(chars) {
+			return ErrCharacterClassInvalid
+		}
+		characters[class[0]] = chars
+	}
+
+	ptt := make([][]byte, len(templates))
+	for i, template := range templates {
+		if template == "" {
+			return ErrTemplateEmpty
+		}
+		if len(template) > maxTemplateLength {
+			return ErrTemplateTooLong
+		}
+		for j := 0; j < len(template); j++ {
+			if _, ok := characters[template[j]]; ok {
+				continue
+			}
+			if _, ok := templateCharacters[template[j]]; !ok {
+				return ErrTemplateClassUnknown
+			}
+		}
+		ptt[i] = []byte(template)
+	}
+
+	passwordTypeTemplates[name] = ptt
+	passwordTypeCharacters[name] = characters
+
+	return nil
+}
+
+// templateClass returns the characters of passwordType's class
+func templateClass(passwordType string, class byte) string {
+	if chars, ok := passwordTypeCharacters[passwordType][class]; ok {
+		return chars
+	}
+
+	return templateCharacters[class]
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7E {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,99 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterPasswordType(t *testing.T) {
+	// exactly 12 characters, at least 2 digits, no ' or ;
+	templates := []string{"CvcvnnCvcvyy", "nnCvcvyyCvcv", "yyCvcvCvcvnn"}
+	classes := map[string]string{"y": "@&%?,=[]_:-+*$#!^~()/."}
+	if !assert.NoError(t, crypto.RegisterPasswordType("corp-policy", templates, classes)) {
+		return
+	}
+	assert.NoError(t, crypto.ValidatePasswordType("corp-policy"))
+	assert.Contains(t, crypto.NewMasterPassword().GetPasswordTypes(), "corp-policy")
+
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+	pw, err := mk.SitePassword("corp-policy", d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "#(DafaXoza08", pw)
+
+	for counter := uint32(1); counter <= 50; counter++ {
+		pw, err = mk.SitePassword("corp-policy", d.s, counter, "auth", "")
+		assert.NoError(t, err)
+		assert.Len(t, pw, 12)
+		assert.Equal(t, 2, len(strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, pw)), pw)
+		assert.False(t, strings.ContainsAny(pw, "';"), pw)
+	}
+
+	// the custom class does not leak into the built-in types
+	pw, err = mk.SitePassword("long", d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "ZedaFaxcZaso9*", pw)
+
+	// custom classes take precedence over the built-in ones
+	assert.NoError(t, crypto.RegisterPasswordType("all-x", []string{"nnnn"}, map[string]string{"n": "x"}))
+	pw, err = mk.SitePassword("all-x", d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "xxxx", pw)
+}
+
+func TestRegisterPasswordTypeBad(t *testing.T) {
+	expectations := []struct {
+		name      string
+		templates []string
+		classes   map[string]string
+		err       error
+	}{
+		{"", []string{"nnnn"}, nil, crypto.ErrPasswordTypeName},
+		{"corp policy", []string{"nnnn"}, nil, crypto.ErrPasswordTypeName},
+		{"long", []string{"nnnn"}, nil, crypto.ErrPasswordTypeExists},
+		{"x", []string{"nnnn"}, nil, crypto.ErrPasswordTypeExists},
+		{"diceware", []string{"nnnn"}, nil, crypto.ErrPasswordTypeExists},
+		{"bad", nil, nil, crypto.ErrTemplateEmpty},
+		{"bad", []string{""}, nil, crypto.ErrTemplateEmpty},
+		{"bad", []string{strings.Repeat("n", 32)}, nil, crypto.ErrTemplateTooLong},
+		{"bad", []string{"nnnq"}, nil, crypto.ErrTemplateClassUnknown},
+		{"bad", []string{"nnnn"}, map[string]string{"yy": "abc"}, crypto.ErrCharacterClassInvalid},
+		{"bad", []string{"nnnn"}, map[string]string{"y": ""}, crypto.ErrCharacterClassInvalid},
+		{"bad", []string{"nnnn"}, map[string]string{"y": "äöü"}, crypto.ErrCharacterClassInvalid},
+	}
+
+	for _, tv := range expectations {
+		assert.Equal(t, tv.err, crypto.RegisterPasswordType(tv.name, tv.templates, tv.classes), "%q %q", tv.name, tv.templates)
+	}
+	assert.Equal(t, crypto.ErrPasswordTypeInvalid, crypto.ValidatePasswordType("bad"))
+}
@@ -0,0 +1,229 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
+)
+
+// DefaultWordSeparator separates the words of the word list password types
+const DefaultWordSeparator = " "
+
+// WordFormat exported errors
+var (
+	ErrWordCaseInvalid  = errors.New("Word case must be one of: lower, title, upper")
+	ErrWordCountInvalid = errors.New("Word count is out of range for the password type")
+)
+
+// WordFormat configures the word list password types (bip39, diceware).
+//
+//   Separator: placed between the words, may be ""
+//   Case:      lower (default), title or upper
+//   Count:     number of words, 0 for the password type's default
+type WordFormat struct {
+	Separator string `json:"separator"`
+	Case      string `json:"case,omitempty"`
+	Count     uint32 `json:"count,omitempty"`
+}
+
+// NewWordFormat returns the WordFormat configured by wc, nil wc returns the defaults
+func NewWordFormat(wc *config.WordsConfig) *WordFormat {
+	wf := &WordFormat{Separator: DefaultWordSeparator}
+	if wc == nil {
+		return wf
+	}
+	if wc.Separator != nil {
+		wf.Separator = *wc.Separator
+	}
+	wf.Case = wc.Case
+	wf.Count = wc.Count
+
+	return wf
+}
+
+// Validate checks wf against passwordType, which is expected to have been validated
+//
+//   NOTE: the word count is not checked for the template password types, which ignore wf
+func (wf *WordFormat) Validate(passwordType string) error {
+	switch wf.Case {
+	case "", "lower", "title", "upper":
+	default:
+		return ErrWordCaseInvalid
+	}
+	if wl, ok := passwordTypeWordLists[PasswordTypeName(passwordType)]; ok && wf.Count != 0 && !wl.validCount(wf.Count) {
+		return ErrWordCountInvalid
+	}
+
+	return nil
+}
+
+// wordList maps a site key to words
+type wordList struct {
+	words        []string
+	defaultCount uint32
+	validCount   func(count uint32) bool
+	encode       func(words []string, seed []byte, count uint32) []string
+	searchSpace  func(words []string, count uint32) *big.Int
+}
+
+var passwordTypeWordLists = map[string]*wordList{
+	// a valid BIP39 mnemonic of 12, 15, 18, 21 or 24 words (128-256 bits)
+	"bip39": {
+		words:        bip39English,
+		defaultCount: 12,
+		validCount:   func(count uint32) bool { return count >= 12 && count <= 24 && count%3 == 0 },
+		encode:       bip39Words,
+		searchSpace:  bip39SearchSpace,
+	},
+	// EFF long list, ~12.9 bits per word, 19 words exhaust the 256 bit site key
+	"diceware": {
+		words:        effLarge,
+		defaultCount: 6,
+		validCount:   func(count uint32) bool { return count >= 1 && count <= 19 },
+		encode:       dicewareWords,
+		searchSpace:  dicewareSearchSpace,
+	},
+}
+
+// password encodes seed as words according to wf, which is expected to have been validated
+func (wl *wordList) password(seed []byte, wf *WordFormat) string {
+	count := wf.Count
+	if count == 0 {
+		count = wl.defaultCount
+	}
+
+	words := wl.encode(wl.words, seed, count)
+	for i, word := range words {
+		words[i] = wordCase(word, wf.Case)
+	}
+
+	return strings.Join(words, wf.Separator)
+}
+
+// wordCase returns word in the case of WordFormat.Case
+func wordCase(word, c string) string {
+	switch c {
+	case "title":
+		return strings.ToUpper(word[:1]) + word[1:]
+	case "upper":
+		return strings.ToUpper(word)
+	}
+
+	return word
+}
+
+// pattern describes the passwords of wl formatted by wf, see CheckPasswordType()
+func (wl *wordList) pattern(wf *WordFormat) *policy.Pattern {
+	count := int(wf.Count)
+	if count == 0 {
+		count = int(wl.defaultCount)
+	}
+	minWord, maxWord := len(wl.words[0]), 0
+	for _, word := range wl.words {
+		if len(word) < minWord {
+			minWord = len(word)
+		}
+		if len(word) > maxWord {
+			maxWord = len(word)
+		}
+	}
+
+	pt := &policy.Pattern{Guarantees: []string{policy.ClassLower}}
+	letters := policy.Classes[policy.ClassLower]
+	switch wf.Case {
+	case "title":
+		letters += policy.Classes[policy.ClassUpper]
+		pt.Guarantees = []string{policy.ClassUpper}
+		if minWord > 1 {
+			pt.Guarantees = append(pt.Guarantees, policy.ClassLower)
+		}
+	case "upper":
+		letters = policy.Classes[policy.ClassUpper]
+		pt.Guarantees = []string{policy.ClassUpper}
+	}
+
+	separators := (count - 1) * len(wf.Separator)
+	pt.Chars = letters
+	if count > 1 {
+		pt.Chars += wf.Separator
+		if class := policy.ClassOf(wf.Separator); class != "" {
+			pt.Guarantees = append(pt.Guarantees, class)
+		}
+	}
+	pt.MinLength = count*minWord + separators
+	pt.MaxLength = count*maxWord + separators
+
+	return pt
+}
+
+// bip39Words encodes the leading count*32/3 bits of seed as a BIP39 mnemonic, including its checksum.
+//
+//   See: https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+func bip39Words(words []string, seed []byte, count uint32) []string {
+	entropy := seed[:count*4/3]
+	checksumBits := uint(count / 3)
+	checksum := sha256.Sum256(entropy)
+
+	// entropy || checksum, as a big endian integer of count*11 bits
+	v := new(big.Int).SetBytes(entropy)
+	v.Lsh(v, checksumBits)
+	v.Or(v, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	mnemonic := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := int(count) - 1; i >= 0; i-- {
+		mnemonic[i] = words[new(big.Int).And(v, mask).Int64()]
+		v.Rsh(v, 11)
+	}
+
+	return mnemonic
+}
+
+// bip39SearchSpace returns 2^(count*32/3), the checksum adding no entropy
+func bip39SearchSpace(words []string, count uint32) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(count*32/3))
+}
+
+// dicewareWords encodes seed, as a big endian integer, in base len(words)
+func dicewareWords(words []string, seed []byte, count uint32) []string {
+	v := new(big.Int).SetBytes(seed)
+	base := big.NewInt(int64(len(words)))
+	m := new(big.Int)
+
+	phrase := make([]string, count)
+	for i := range phrase {
+		v.DivMod(v, base, m)
+		phrase[i] = words[m.Int64()]
+	}
+
+	return phrase
+}
+
+// dicewareSearchSpace returns len(words)^count
+func dicewareSearchSpace(words []string, count uint32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(int64(len(words))), big.NewInt(int64(count)), nil)
+}
@@ -0,0 +1,68 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBIP39Words uses the BIP39 reference vectors, see:
+//   https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+func TestBIP39Words(t *testing.T) {
+	expectations := []struct {
+		entropy, mnemonic string
+	}{
+		{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+		{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"},
+		{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+		{"9e885d952ad362caeb4efe34a8e91bd2", "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic"},
+		{"0000000000000000000000000000000000000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"},
+		{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote"},
+	}
+
+	for _, tv := range expectations {
+		entropy, err := hex.DecodeString(tv.entropy)
+		if !assert.NoError(t, err) {
+			return
+		}
+		// the site key is always 32 bytes, only the leading entropy bytes may be used
+		seed := append(entropy, make([]byte, 32-len(entropy))...)
+		for i := len(entropy); i < len(seed); i++ {
+			seed[i] = 0xA5
+		}
+		count := uint32(len(entropy) * 3 / 4)
+		assert.Equal(t, tv.mnemonic, strings.Join(bip39Words(bip39English, seed, count), " "))
+	}
+}
+
+func TestWordLists(t *testing.T) {
+	assert.Len(t, bip39English, 2048)
+	assert.Len(t, effLarge, 7776)
+	assert.Equal(t, "abacus", effLarge[0])
+	assert.Equal(t, "zoom", effLarge[7775])
+}
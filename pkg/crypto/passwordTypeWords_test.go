@@ -0,0 +1,93 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/config"
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMasterKeySitePasswordWords(t *testing.T) {
+	mk, err := crypto.NewMasterKey(mpwseeds[0], d.u, d.pw, 3)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	pw, err := mk.SitePassword("diceware", d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "playgroup festival profound navy slinging magnitude", pw)
+
+	pw, err = mk.SitePassword("bip39", d.s, 1, "auth", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "elegant nation parent mail march maximum vocal good metal nature empower fortune", pw)
+
+	separator := "-"
+	expectations := []struct {
+		pt     string
+		wc     *config.WordsConfig
+		expect string
+	}{
+		{"diceware", &config.WordsConfig{Separator: &separator, Case: "title", Count: 3}, "Playgroup-Festival-Profound"},
+		{"diceware", &config.WordsConfig{Case: "upper", Count: 2}, "PLAYGROUP FESTIVAL"},
+		{"bip39", &config.WordsConfig{Count: 24},
+			"elegant nation parent mail march maximum vocal good metal nature empower food hole stay cliff emotion cinnamon fragile dignity gaze pretty mirror olympic swap"},
+		// template types ignore the WordFormat
+		{"long", &config.WordsConfig{Case: "upper", Count: 99}, "ZedaFaxcZaso9*"},
+	}
+	for _, tv := range expectations {
+		pw, err = mk.SitePasswordEX(tv.pt, &crypto.PasswordOptions{Words: crypto.NewWordFormat(tv.wc)}, d.s, 1, "auth", "")
+		assert.NoError(t, err)
+		assert.Equal(t, tv.expect, pw)
+	}
+
+	_, err = mk.SitePasswordEX("bip39", &crypto.PasswordOptions{Words: &crypto.WordFormat{Count: 13}}, d.s, 1, "auth", "")
+	assert.Equal(t, crypto.ErrWordCountInvalid, err)
+	_, err = mk.SitePasswordEX("diceware", &crypto.PasswordOptions{Words: &crypto.WordFormat{Count: 20}}, d.s, 1, "auth", "")
+	assert.Equal(t, crypto.ErrWordCountInvalid, err)
+	_, err = mk.SitePasswordEX("diceware", &crypto.PasswordOptions{Words: &crypto.WordFormat{Case: "camel"}}, d.s, 1, "auth", "")
+	assert.Equal(t, crypto.ErrWordCaseInvalid, err)
+}
+
+func TestMasterPasswordWords(t *testing.T) {
+	separator := ""
+	m := crypto.NewMasterPassword()
+	m.Config = &config.MPConfig{
+		MasterPasswordSeed: mpwseeds[0],
+		PasswordPurpose:    "auth",
+		PasswordType:       "diceware",
+		Fullname:           d.u,
+		Password:           d.pw,
+		Site:               d.s,
+		Words:              &config.WordsConfig{Separator: &separator, Case: "title", Count: 4},
+		Counter:            1,
+	}
+	pw, err := m.MasterPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "PlaygroupFestivalProfoundNavy", pw)
+
+	assert.NoError(t, crypto.ValidatePasswordType("bip39"))
+	assert.NoError(t, crypto.ValidatePasswordType("diceware"))
+	assert.Contains(t, m.GetPasswordTypes(), "bip39")
+	assert.Contains(t, m.GetPasswordTypes(), "diceware")
+}
@@ -0,0 +1,126 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto
+
+import (
+	"errors"
+)
+
+// Secret exported errors
+var (
+	ErrSecretClosed = errors.New("Secret has been closed")
+)
+
+// Secret holds sensitive data, i.e. the master password, master key and site keys, outside of the Go heap.
+//
+//   The data is mmap'd between two PROT_NONE guard pages, locked into memory (best effort, see Mlock())
+//   and zeroed on Close(), so it is neither moved nor copied by the garbage collector.
+//
+//   NOTE: platforms lacking mmap(2) fall back to a heap buffer, which is still zeroed on Close()
+//   NOTE: a nil *Secret is an empty, closed Secret
+type Secret struct {
+	data []byte
+	addr uintptr // of the mapping, see munmapGuarded()
+}
+
+// NewSecret returns a zeroed Secret of size bytes
+func NewSecret(size int) (*Secret, error) {
+	addr, data, err := mmapGuarded(size)
+	if err != nil {
+		return nil, err
+	}
+	s := &Secret{data: data, addr: addr}
+	// best effort, callers needing to know may call Mlock() themselves
+	_ = s.Mlock()
+
+	return s, nil
+}
+
+// NewSecretBytes returns a Secret holding a copy of buf, which is zeroed
+func NewSecretBytes(buf []byte) (*Secret, error) {
+	s, err := NewSecret(len(buf))
+	if err != nil {
+		return nil, err
+	}
+	copy(s.data, buf)
+	zero(buf)
+
+	return s, nil
+}
+
+// NewSecretString returns a Secret holding a copy of str.
+//
+//   NOTE: Go strings are immutable, so str itself can't be wiped
+func NewSecretString(str string) (*Secret, error) {
+	s, err := NewSecret(len(str))
+	if err != nil {
+		return nil, err
+	}
+	copy(s.data, str)
+
+	return s, nil
+}
+
+// Bytes returns the Secret's data, which is only valid until Close(), nil if closed
+func (s *Secret) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+
+	return s.data
+}
+
+// Len returns the length of the Secret's data, 0 if closed
+func (s *Secret) Len() int {
+	return len(s.Bytes())
+}
+
+// Mlock locks the Secret into memory, preventing it from being paged out to swap.
+//
+//   NOTE: a no-op on platforms lacking mlock(2)
+func (s *Secret) Mlock() error {
+	if s == nil || s.data == nil {
+		return ErrSecretClosed
+	}
+	if len(s.data) == 0 {
+		return nil
+	}
+
+	return mlock(s.data)
+}
+
+// Close zeroes and releases the Secret, it is safe to call more than once
+func (s *Secret) Close() error {
+	if s == nil || s.data == nil {
+		return nil
+	}
+
+	zero(s.data)
+	// harmless if the Secret was never mlocked
+	if len(s.data) > 0 {
+		_ = munlock(s.data)
+	}
+	err := munmapGuarded(s.addr)
+	s.data = nil
+	s.addr = 0
+
+	return err
+}
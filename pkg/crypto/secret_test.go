@@ -0,0 +1,78 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package crypto_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecret(t *testing.T) {
+	buf := []byte("banana colored duckling")
+	s, err := crypto.NewSecretBytes(buf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, make([]byte, len(buf)), buf, "source must be zeroed")
+	assert.Equal(t, "banana colored duckling", string(s.Bytes()))
+	assert.Equal(t, 23, s.Len())
+	// appending must never reach the guard page
+	assert.Equal(t, s.Len(), cap(s.Bytes()))
+	assert.NoError(t, s.Mlock())
+
+	assert.NoError(t, s.Close())
+	assert.Nil(t, s.Bytes())
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, crypto.ErrSecretClosed, s.Mlock())
+	// idempotent
+	assert.NoError(t, s.Close())
+
+	s, err = crypto.NewSecretString("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, s.Len())
+		assert.NoError(t, s.Mlock())
+		assert.NoError(t, s.Close())
+	}
+
+	// a nil Secret is empty and closed
+	var ns *crypto.Secret
+	assert.Nil(t, ns.Bytes())
+	assert.NoError(t, ns.Close())
+}
+
+// secrets spanning several pages
+func TestSecretLarge(t *testing.T) {
+	for _, size := range []int{4095, 4096, 4097, 65536} {
+		s, err := crypto.NewSecret(size)
+		if !assert.NoError(t, err, size) {
+			continue
+		}
+		buf := s.Bytes()
+		assert.Equal(t, size, len(buf))
+		for i := range buf {
+			assert.Equal(t, byte(0), buf[i])
+			buf[i] = 0xff
+		}
+		assert.NoError(t, s.Close(), size)
+	}
+}
@@ -20,6 +20,10 @@
 
 package crypto
 
+/*
+ * SetAlgorithmVersion(): algorithmVersion.go
+ */
+
 // SetCounter is a setter for MasterPW.counter
 func (mpw *MasterPW) SetCounter(counter uint32) (err error) {
 	if err = ValidateCounter(counter); err == nil {
@@ -36,6 +40,24 @@ func (mpw *MasterPW) SetFullname(fullname string) (err error) {
 	return
 }
 
+// SetKeyContext is a setter for MasterPW.keyContext
+//
+//   NOTE: "" is valid and disables the keyContext
+func (mpw *MasterPW) SetKeyContext(keyContext string) error {
+	mpw.keyContext = keyContext
+	return nil
+}
+
+// SetKeyID is a setter for MasterPW.keyID
+//
+//   NOTE: "" is valid and disables the master key ID verification
+func (mpw *MasterPW) SetKeyID(keyID string) (err error) {
+	if err = ValidateKeyID(keyID); err == nil {
+		mpw.keyID = keyID
+	}
+	return
+}
+
 // SetMasterPasswordSeed is a setter for MasterPW.masterPasswordSeed
 func (mpw *MasterPW) SetMasterPasswordSeed(seed string) (err error) {
 	if err = ValidateMasterPasswordSeed(seed); err == nil {
@@ -44,10 +66,15 @@ func (mpw *MasterPW) SetMasterPasswordSeed(seed string) (err error) {
 	return
 }
 
-// SetPassword is a setter for MasterPW.password
+// SetPassword is a setter for MasterPW.password, replacing (and zeroing) any previous one
 func (mpw *MasterPW) SetPassword(password string) (err error) {
-	if err = ValidatePassword(password); err == nil {
-		mpw.password = password
+	if err = ValidatePassword(password); err != nil {
+		return
+	}
+	var secret *Secret
+	if secret, err = NewSecretString(password); err == nil {
+		_ = mpw.password.Close()
+		mpw.password = secret
 	}
 	return
 }
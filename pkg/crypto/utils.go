@@ -31,3 +31,19 @@ func mpwIDBuf(buf []byte) string {
 
 	return fmt.Sprintf("%02X", sum)
 }
+
+// zero overwrites buf, for key material no longer needed
+func zero(buf []byte) {
+	for i := range buf {
+		buf[i] = 0
+	}
+}
+
+// orNull mimics mpw's "(null)" debug output for "" elements
+func orNull(v string) string {
+	if v != "" {
+		return v
+	}
+
+	return "(null)"
+}
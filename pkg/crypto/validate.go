@@ -21,6 +21,8 @@
 package crypto
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 )
 
@@ -28,6 +30,7 @@ import (
 var (
 	ErrCounter                 = errors.New("site password counter must be >= 1")
 	ErrFullnameEmpty           = errors.New("Site fullname must be set")
+	ErrKeyIDInvalid            = errors.New("Master key ID must be 64 hexadecimal characters")
 	ErrMasterPasswordSeedEmpty = errors.New("MasterPassword seed must be set")
 	ErrPasswordEmpty           = errors.New("Site password must be set")
 	ErrPasswordTypeEmpty       = errors.New("Password type must be set")
@@ -38,16 +41,23 @@ var (
 // Validate ensures that MasterPW is ready for MasterPassword().
 //
 //   1) masterPasswordSeed
-//   2) passwordType
-//   3) passwordPurpose
-//   4) fullname
-//   5) password
-//   6) site
-//   7) counter
+//   2) algorithmVersion
+//   3) passwordType
+//   4) passwordPurpose
+//   5) fullname
+//   6) password
+//   7) site
+//   8) counter
+//   9) keyID
+//  10) wordFormat
+//  11) policy
 func (mpw *MasterPW) Validate() error {
 	if err := ValidateMasterPasswordSeed(mpw.masterPasswordSeed); err != nil {
 		return err
 	}
+	if err := mpw.ValidateAlgorithmVersion(); err != nil {
+		return err
+	}
 	if err := ValidatePasswordType(mpw.passwordType); err != nil {
 		return err
 	}
@@ -57,8 +67,8 @@ func (mpw *MasterPW) Validate() error {
 	if err := ValidateFullname(mpw.fullname); err != nil {
 		return err
 	}
-	if err := ValidatePassword(mpw.password); err != nil {
-		return err
+	if mpw.password.Len() == 0 {
+		return ErrPasswordEmpty
 	}
 	if err := ValidateSite(mpw.site); err != nil {
 		return err
@@ -66,6 +76,19 @@ func (mpw *MasterPW) Validate() error {
 	if err := ValidateCounter(mpw.counter); err != nil {
 		return err
 	}
+	if err := ValidateKeyID(mpw.keyID); err != nil {
+		return err
+	}
+	if mpw.wordFormat != nil {
+		if err := mpw.wordFormat.Validate(mpw.passwordType); err != nil {
+			return err
+		}
+	}
+	if mpw.policy != nil {
+		if err := mpw.policy.Validate(); err != nil {
+			return err
+		}
+	}
 
 	// Extra test to catch the following constraints:
 	//   0 > auth >= 1
@@ -80,6 +103,10 @@ func (mpw *MasterPW) Validate() error {
 	return nil
 }
 
+/*
+ * ValidateAlgorithmVersion(): algorithmVersion.go
+ */
+
 // ValidateCounter validates that the site counter value is >= 1
 func ValidateCounter(counter uint32) error {
 	if counter < 1 {
@@ -98,6 +125,21 @@ func ValidateFullname(fullname string) error {
 	return nil
 }
 
+// ValidateKeyID validates that keyID is either "" or a hex encoded sha256 sum
+func ValidateKeyID(keyID string) error {
+	if keyID == "" {
+		return nil
+	}
+	if len(keyID) != sha256.Size*2 {
+		return ErrKeyIDInvalid
+	}
+	if _, err := hex.DecodeString(keyID); err != nil {
+		return ErrKeyIDInvalid
+	}
+
+	return nil
+}
+
 // ValidateMasterPasswordSeed validates that seed is not empty
 func ValidateMasterPasswordSeed(seed string) error {
 	if seed == "" {
@@ -127,7 +169,7 @@ func ValidatePasswordType(passwordType string) error {
 	}
 
 	_, exists := passwordTypeTemplates[passwordType]
-	if !exists {
+	if _, words := passwordTypeWordLists[passwordType]; !exists && !words {
 		return ErrPasswordTypeInvalid
 	}
 
@@ -27,6 +27,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestValidateAlgorithmVersion(t *testing.T) {
+	// good
+	for v := uint32(0); v <= 3; v++ {
+		assert.NoError(t, crypto.ValidateAlgorithmVersion(v))
+	}
+
+	// bad
+	assert.Error(t, crypto.ErrAlgorithmVersionInvalid, crypto.ValidateAlgorithmVersion(4))
+}
+
 func TestValidateCounter(t *testing.T) {
 	// good
 	assert.NoError(t, crypto.ValidateCounter(1))
@@ -44,6 +54,16 @@ func TestValidateFullname(t *testing.T) {
 	assert.Error(t, crypto.ErrFullnameEmpty, crypto.ValidateFullname(""))
 }
 
+func TestValidateKeyID(t *testing.T) {
+	// good
+	assert.NoError(t, crypto.ValidateKeyID(""))
+	assert.NoError(t, crypto.ValidateKeyID("98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302"))
+
+	// bad
+	assert.Error(t, crypto.ErrKeyIDInvalid, crypto.ValidateKeyID("98EEF4D1"))
+	assert.Error(t, crypto.ErrKeyIDInvalid, crypto.ValidateKeyID("ZZEEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302"))
+}
+
 // ValidateMasterPasswordSeed validates that seed is not empty
 func TestValidateMasterPasswordSeed(t *testing.T) {
 	// good
@@ -21,28 +21,41 @@
 package debug
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 )
 
 var globalDebug *Debug
 
 // Debug provides access to the debugging methods.
-// It provides for scoped logging based on file suffix matching.
+// It provides for scoped logging based on file (or directory) suffix matching, with levels, key/value fields and a
+// configurable format and output (see Config).
 type Debug struct {
 	enabled bool
 	files   []string
+	level   Level
+	encode  encoder
+	out     *output
+	mu      sync.Mutex // guards the above, as Configure() may run concurrently, and serializes the writes to out
 }
 
 func init() {
 	globalDebug = NewDebug()
 	mpDebug := os.Getenv("MP_DEBUG")
 	if mpDebug != "" {
-		globalDebug.files = strings.Split(mpDebug, ",")
-		globalDebug.enabled = len(globalDebug.files) > 0
+		c, err := ParseSpec(mpDebug)
+		if err == nil {
+			err = globalDebug.Configure(c)
+		}
+		if err != nil {
+			logIt("MP_DEBUG: %s", err)
+		}
 	}
 }
 
@@ -52,50 +65,149 @@ func NewDebug() *Debug {
 		return globalDebug
 	}
 	globalDebug = &Debug{
-		files: make([]string, 0, 1),
+		files:  make([]string, 0, 1),
+		encode: encodeText,
+		out:    &output{},
 	}
 
 	return globalDebug
 }
 
+// Configure enables the Debug according to c, replacing any previous configuration.
+//
+//   An empty Scope is "all", so e.g. only setting the output suffices.
+func (d *Debug) Configure(c *Config) error {
+	level, err := ParseLevel(c.Level)
+	if err != nil {
+		return err
+	}
+	format := c.Format
+	if format == "" {
+		format = FormatText
+	}
+	encode, ok := encoders[format]
+	if !ok {
+		return ErrFormatInvalid
+	}
+	out, err := openOutput(c.Output)
+	if err != nil {
+		return err
+	}
+	// JSON can't share the standard logger's timestamp prefix
+	if format == FormatJSON && out.w == nil {
+		out.w = os.Stderr
+	}
+	out.stamp = format == FormatText && !out.syslog
+
+	files := []string{"all"}
+	if len(c.Scope) > 0 {
+		files = make([]string, 0, len(c.Scope))
+		for _, f := range c.Scope {
+			if f != "all" {
+				f = normalize(f)
+			}
+			files = append(files, f)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_ = d.out.close()
+	d.files = files
+	d.level = level
+	d.encode = encode
+	d.out = out
+	d.enabled = true
+
+	return nil
+}
+
 // Dbg only outputs if debugging is in effect
 func (d *Debug) Dbg(format string, a ...interface{}) {
-	if !d.enabled {
+	_, f, line, ok := runtime.Caller(1)
+	if !ok || !d.want(LevelDebug, f) {
 		return
 	}
 
-	_, f, _, ok := runtime.Caller(1)
-	if !ok {
+	d.emit(LevelDebug, f, line, fmt.Sprintf(format, a...), nil)
+}
+
+// DbgO (O is override) will output regardless if debugging is in effect or not
+func (d *Debug) DbgO(format string, a ...interface{}) {
+	_, f, line, _ := runtime.Caller(1)
+	d.emit(LevelDebug, f, line, fmt.Sprintf(format, a...), nil)
+}
+
+// Log outputs msg with the key/value fields, if debugging is in effect for the caller at level.
+//
+//   e.g. Log(LevelInfo, "siteKey", "siteName", site, "siteCounter", counter)
+//
+//   NOTE: secrets must be passed as Secret, SecretBytes or SecretID, so that they are redacted
+func (d *Debug) Log(level Level, msg string, fields ...interface{}) {
+	d.LogDepth(1, level, msg, fields...)
+}
+
+// LogDepth is Log() for wrappers, skip being the number of their frames, so the scope and the caller
+// refer to the wrapper's caller.
+//
+//   e.g. func debug(msg string) { NewDebug().LogDepth(1, LevelDebug, msg) }
+func (d *Debug) LogDepth(skip int, level Level, msg string, fields ...interface{}) {
+	_, f, line, ok := runtime.Caller(1 + skip)
+	if !ok || !d.want(level, f) {
 		return
 	}
 
-	if !d.wantDebug(f) {
-		return
+	if len(fields)%2 != 0 {
+		fields = append(fields, "(MISSING)")
 	}
+	d.emit(level, f, line, msg, fields)
+}
 
-	logIt(format, a...)
+// want reports if debugging is in effect for the caller's file f at level
+func (d *Debug) want(level Level, f string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.enabled && level >= d.level && d.wantDebug(f)
 }
 
-// DbgO (O is override) will output regardless if debugging is in effect or not
-func (d *Debug) DbgO(format string, a ...interface{}) {
-	logIt(format, a...)
+// emit encodes and writes a record
+func (d *Debug) emit(level Level, file string, line int, msg string, fields []interface{}) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r := &record{
+		time:   time.Now(),
+		level:  level,
+		file:   shortFile(file),
+		line:   line,
+		msg:    msg,
+		fields: fields,
+	}
+	if err := d.out.write(r, d.encode(r)); err != nil {
+		logIt("debug output: %s", err)
+	}
 }
 
 // SetFilename appends given filepath to Debug's stored file list
 func (d *Debug) SetFilename(f string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	// Stores the filename normalized
 	d.files = append(d.files, normalize(f))
 	d.enabled = true
 }
 
-// wantDebug will do a suffix based match for given filepath
+// wantDebug will do a suffix based match for given filepath, or its directory, d.mu is expected to be held
 //
-//  e.g. /^.*${fp}$/
+//   e.g. /^.*${fp}$/ or /^.*${fp}\/.*$/
 func (d *Debug) wantDebug(cf string) bool {
 	//cf == caller filename
 	cf = normalize(cf)
 	for _, fn := range d.files {
-		if fn == "all" || strings.HasSuffix(cf, fn) {
+		if fn == "all" || strings.HasSuffix(cf, fn) || strings.Contains(cf, fn+"/") {
 			return true
 		}
 	}
@@ -107,6 +219,18 @@ func logIt(format string, a ...interface{}) {
 	log.Printf("[DEBUG] "+format, a...)
 }
 
+// shortFile returns the last two elements of the caller's file, e.g. "crypto/masterKey.go"
+func shortFile(f string) string {
+	f = filepath.ToSlash(f)
+	if i := strings.LastIndex(f, "/"); i > 0 {
+		if j := strings.LastIndex(f[:i], "/"); j >= 0 {
+			return f[j+1:]
+		}
+	}
+
+	return f
+}
+
 func normalize(f string) string {
 	// 1) make sure it has a leading slash
 	// 2) cleaned up
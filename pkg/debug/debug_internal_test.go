@@ -46,6 +46,12 @@ func Test_wantDebug(t *testing.T) {
 	assert.True(t, d.wantDebug("a/b/c/foo/bar.go"))
 	assert.False(t, d.wantDebug("bar.go"))
 	assert.False(t, d.wantDebug("ar.go"))
+
+	// directories
+	d.SetFilename("cmd/gompw")
+	assert.True(t, d.wantDebug("/src/cmd/gompw/main.go"))
+	assert.False(t, d.wantDebug("/src/cmd/gompwx/main.go"))
+	assert.False(t, d.wantDebug("/src/gompw/main.go"))
 }
 
 func TestDbg(t *testing.T) {
@@ -54,7 +60,7 @@ func TestDbg(t *testing.T) {
 
 	expect := "ZtesTingZ"
 	output := captureLogOutput(func() {
-		d.Dbg(expect)
+		d.Dbg("%s", expect)
 	})
 	assert.Contains(t, output, expect)
 }
@@ -0,0 +1,177 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package debug
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Redaction levels of the secret values, see SetRedaction()
+const (
+	RedactFull Redaction = iota // "[REDACTED]"
+	RedactID                    // the secret's hashed ID, e.g. to compare two runs' master passwords
+	RedactNone                  // cleartext, requires MP_DEBUG_UNSAFE
+)
+
+// debug exported errors
+var (
+	ErrRedactionInvalid = errors.New("Debug redaction must be one of: full, id, none")
+	ErrRedactionUnsafe  = errors.New("Debug redaction 'none' requires MP_DEBUG_UNSAFE")
+)
+
+// Redaction is how the secret values are logged
+type Redaction int
+
+var redactions = map[string]Redaction{
+	"full": RedactFull,
+	"id":   RedactID,
+	"none": RedactNone,
+}
+
+var redaction = struct {
+	sync.RWMutex
+	level Redaction
+}{}
+
+func init() {
+	// MP_DEBUG_REDACT=id|full, MP_DEBUG_UNSAFE implies none
+	level, err := ParseRedaction(os.Getenv("MP_DEBUG_REDACT"))
+	if err != nil {
+		logIt("MP_DEBUG_REDACT: %s, using full", err)
+	}
+	if os.Getenv("MP_DEBUG_UNSAFE") != "" {
+		level = RedactNone
+	}
+	if err := SetRedaction(level); err != nil {
+		logIt("%s, using full", err)
+	}
+}
+
+// ParseRedaction returns the Redaction of name: full, id or none ("" is full)
+func ParseRedaction(name string) (Redaction, error) {
+	if name == "" {
+		return RedactFull, nil
+	}
+	if r, ok := redactions[strings.ToLower(name)]; ok {
+		return r, nil
+	}
+
+	return RedactFull, ErrRedactionInvalid
+}
+
+// String implements fmt.Stringer
+func (r Redaction) String() string {
+	for name, level := range redactions {
+		if level == r {
+			return name
+		}
+	}
+
+	return fmt.Sprintf("Redaction(%d)", int(r))
+}
+
+// SetRedaction sets how the secret values are logged, RedactNone requires MP_DEBUG_UNSAFE to be set
+func SetRedaction(r Redaction) error {
+	if r < RedactFull || r > RedactNone {
+		return ErrRedactionInvalid
+	}
+	if r == RedactNone && os.Getenv("MP_DEBUG_UNSAFE") == "" {
+		return ErrRedactionUnsafe
+	}
+
+	redaction.Lock()
+	redaction.level = r
+	redaction.Unlock()
+
+	return nil
+}
+
+// GetRedaction returns how the secret values are logged
+func GetRedaction() Redaction {
+	redaction.RLock()
+	defer redaction.RUnlock()
+
+	return redaction.level
+}
+
+// Secret is a string which is redacted when formatted, e.g. by Dbg()
+type Secret string
+
+// Format implements fmt.Formatter, regardless of the verb
+func (s Secret) Format(f fmt.State, verb rune) {
+	format(f, verb, redact([]byte(s)))
+}
+
+// SecretBytes is a []byte which is redacted when formatted, e.g. by Dbg()
+type SecretBytes []byte
+
+// Format implements fmt.Formatter, regardless of the verb
+func (s SecretBytes) Format(f fmt.State, verb rune) {
+	format(f, verb, redact(s))
+}
+
+// SecretID is an ID derived from a secret (e.g. masterPassword.id), only shown from RedactID on
+type SecretID string
+
+// Format implements fmt.Formatter, regardless of the verb
+func (s SecretID) Format(f fmt.State, verb rune) {
+	v := string(s)
+	if GetRedaction() == RedactFull {
+		v = redacted
+	}
+	format(f, verb, v)
+}
+
+// Redact returns v as it would be logged as a Secret, "" stays ""
+func Redact(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return redact([]byte(v))
+}
+
+const redacted = "[REDACTED]"
+
+func redact(v []byte) string {
+	switch GetRedaction() {
+	case RedactID:
+		// as mpw's mpw_id_buf()
+		return fmt.Sprintf("[ID:%02X]", sha256.Sum256(v))
+	case RedactNone:
+		return string(v)
+	}
+
+	return redacted
+}
+
+func format(f fmt.State, verb rune, v string) {
+	if verb == 'q' {
+		fmt.Fprintf(f, "%q", v)
+		return
+	}
+	fmt.Fprint(f, v)
+}
@@ -0,0 +1,97 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package debug
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	secret   = "banana colored duckling"
+	secretID = "[ID:A720D6A4207533DA9854558B153A41E055AF32D9EC1F2C616F908E998E50372F]"
+)
+
+func TestRedaction(t *testing.T) {
+	defer SetRedaction(RedactFull)
+
+	expectations := []struct {
+		level    Redaction
+		expected string
+	}{
+		{RedactFull, "[REDACTED]"},
+		{RedactID, secretID},
+	}
+	for _, tv := range expectations {
+		assert.NoError(t, SetRedaction(tv.level))
+		assert.Equal(t, tv.level, GetRedaction())
+		for _, format := range []string{"%s", "%v", "%x", "%d"} {
+			assert.Equal(t, tv.expected, fmt.Sprintf(format, Secret(secret)), "%s %s", tv.level, format)
+			assert.Equal(t, tv.expected, fmt.Sprintf(format, SecretBytes(secret)), "%s %s", tv.level, format)
+		}
+		assert.Equal(t, fmt.Sprintf("%q", tv.expected), fmt.Sprintf("%q", Secret(secret)))
+		assert.Equal(t, tv.expected, Redact(secret))
+		assert.Equal(t, "", Redact(""))
+	}
+
+	// the hashed IDs are only shown from RedactID on
+	assert.NoError(t, SetRedaction(RedactFull))
+	assert.Equal(t, "[REDACTED]", fmt.Sprint(SecretID("5E6F")))
+	assert.NoError(t, SetRedaction(RedactID))
+	assert.Equal(t, "5E6F", fmt.Sprint(SecretID("5E6F")))
+
+	// cleartext requires MP_DEBUG_UNSAFE
+	os.Unsetenv("MP_DEBUG_UNSAFE")
+	assert.Equal(t, ErrRedactionUnsafe, SetRedaction(RedactNone))
+	assert.Equal(t, RedactID, GetRedaction())
+	os.Setenv("MP_DEBUG_UNSAFE", "1")
+	defer os.Unsetenv("MP_DEBUG_UNSAFE")
+	assert.NoError(t, SetRedaction(RedactNone))
+	assert.Equal(t, secret, fmt.Sprintf("%s", Secret(secret)))
+	assert.Equal(t, secret, Redact(secret))
+
+	assert.Equal(t, ErrRedactionInvalid, SetRedaction(Redaction(42)))
+}
+
+func TestParseRedaction(t *testing.T) {
+	for name, level := range map[string]Redaction{"": RedactFull, "full": RedactFull, "ID": RedactID, "none": RedactNone} {
+		r, err := ParseRedaction(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, level, r, name)
+	}
+	_, err := ParseRedaction("partial")
+	assert.Equal(t, ErrRedactionInvalid, err)
+	assert.Equal(t, "id", RedactID.String())
+}
+
+func TestDbgRedacted(t *testing.T) {
+	d := NewDebug()
+	d.SetFilename("debug/redact_internal_test.go")
+
+	output := captureLogOutput(func() {
+		d.Dbg("password: %s", Secret(secret))
+	})
+	assert.Contains(t, output, "password: [REDACTED]")
+	assert.NotContains(t, output, "duckling")
+}
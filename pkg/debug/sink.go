@@ -0,0 +1,290 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Log levels, records below the configured level are dropped
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Output formats, see Config.Format
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// sink exported errors
+var (
+	ErrFormatInvalid = errors.New("Debug format must be one of: text, json")
+	ErrLevelInvalid  = errors.New("Debug level must be one of: debug, info, warn, error")
+	ErrOutputInvalid = errors.New("Debug output must be stderr, fd:N, syslog[:output] or a file path")
+	ErrSpecInvalid   = errors.New("Debug option must be one of: level=, format=, output=")
+)
+
+// Level is the severity of a record
+type Level int
+
+var levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel returns the Level of name: debug, info, warn or error ("" is debug)
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return LevelDebug, nil
+	}
+	for i, l := range levels {
+		if strings.EqualFold(name, l) {
+			return Level(i), nil
+		}
+	}
+
+	return LevelDebug, ErrLevelInvalid
+}
+
+// String implements fmt.Stringer
+func (l Level) String() string {
+	if l >= LevelDebug && int(l) < len(levels) {
+		return levels[l]
+	}
+
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Config configures a Debug, see Configure().
+//
+//   MP_DEBUG holds the same as a comma separated spec, see ParseSpec():
+//     MP_DEBUG=crypto/masterKey.go,level=info,format=json,output=/tmp/gompw.trace
+type Config struct {
+	Scope  []string // caller file (or directory) suffixes, "all" (the default) for every file
+	Level  string   // debug (default), info, warn or error
+	Format string   // text (default) or json
+	Output string   // stderr (default), fd:N, syslog[:output] or a file path (appended to)
+}
+
+// ParseSpec parses the MP_DEBUG syntax: file suffixes and level=, format=, output= options, comma separated
+func ParseSpec(spec string) (*Config, error) {
+	c := &Config{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			c.Scope = append(c.Scope, item)
+			continue
+		}
+		switch k, v := item[:i], item[i+1:]; k {
+		case "level":
+			c.Level = v
+		case "format":
+			c.Format = v
+		case "output":
+			c.Output = v
+		default:
+			return nil, ErrSpecInvalid
+		}
+	}
+
+	return c, nil
+}
+
+// record is a single log entry
+type record struct {
+	time   time.Time
+	level  Level
+	file   string // caller, shortened to its last two path elements
+	line   int
+	msg    string
+	fields []interface{} // key, value pairs
+}
+
+// encoder renders a record, without a trailing newline
+type encoder func(r *record) []byte
+
+var encoders = map[string]encoder{
+	FormatText: encodeText,
+	FormatJSON: encodeJSON,
+}
+
+// encodeText renders "[LEVEL] msg key=value ..."
+func encodeText(r *record) []byte {
+	var b bytes.Buffer
+	b.WriteString("[" + strings.ToUpper(r.level.String()) + "] ")
+	b.WriteString(r.msg)
+	for i := 0; i < len(r.fields); i += 2 {
+		fmt.Fprintf(&b, " %s=%s", fmt.Sprint(r.fields[i]), quoteText(fmt.Sprint(r.fields[i+1])))
+	}
+
+	return b.Bytes()
+}
+
+func quoteText(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+		return strconv.Quote(v)
+	}
+
+	return v
+}
+
+// encodeJSON renders a single line JSON object, the fields following time, level, caller and msg
+func encodeJSON(r *record) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"time":`)
+	b.Write(jsonValue(r.time.Format(time.RFC3339Nano)))
+	b.WriteString(`,"level":`)
+	b.Write(jsonValue(r.level.String()))
+	b.WriteString(`,"caller":`)
+	b.Write(jsonValue(r.file + ":" + strconv.Itoa(r.line)))
+	b.WriteString(`,"msg":`)
+	b.Write(jsonValue(r.msg))
+	for i := 0; i < len(r.fields); i += 2 {
+		b.WriteByte(',')
+		b.Write(jsonValue(fmt.Sprint(r.fields[i])))
+		b.WriteByte(':')
+		b.Write(jsonValue(r.fields[i+1]))
+	}
+	b.WriteByte('}')
+
+	return b.Bytes()
+}
+
+// jsonValue marshals v, falling back to its fmt representation (e.g. for errors and Stringers)
+func jsonValue(v interface{}) []byte {
+	switch v.(type) {
+	case json.Marshaler, string, bool, nil,
+		int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		if buf, err := json.Marshal(v); err == nil {
+			return buf
+		}
+	}
+	buf, _ := json.Marshal(fmt.Sprint(v))
+
+	return buf
+}
+
+// output writes the encoded records
+type output struct {
+	w      io.Writer // nil for the standard logger, as by log.Printf
+	closer io.Closer // set if opened by openOutput()
+	syslog bool      // RFC 5424 framing
+	stamp  bool      // prefix a timestamp, as the standard logger would
+	app    string
+	host   string
+}
+
+// openOutput opens spec: stderr, fd:N, syslog[:output] or a file path
+func openOutput(spec string) (*output, error) {
+	if strings.HasPrefix(spec, "syslog") {
+		if spec != "syslog" && !strings.HasPrefix(spec, "syslog:") {
+			return nil, ErrOutputInvalid
+		}
+		o, err := openOutput(strings.TrimPrefix(strings.TrimPrefix(spec, "syslog"), ":"))
+		if err != nil {
+			return nil, err
+		}
+		if o.w == nil {
+			o.w = os.Stderr
+		}
+		o.syslog = true
+		o.app = filepath.Base(os.Args[0])
+		if o.host, err = os.Hostname(); err != nil || o.host == "" {
+			o.host = "-"
+		}
+		return o, nil
+	}
+
+	switch {
+	case spec == "" || spec == "stderr":
+		return &output{}, nil
+	case strings.HasPrefix(spec, "fd:"):
+		fd, err := strconv.ParseUint(spec[3:], 10, 32)
+		if err != nil {
+			return nil, ErrOutputInvalid
+		}
+		return &output{w: os.NewFile(uintptr(fd), spec)}, nil
+	}
+
+	f, err := os.OpenFile(spec, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &output{w: f, closer: f}, nil
+}
+
+// syslogSeverity maps the levels to RFC 5424 severities
+var syslogSeverity = map[Level]int{
+	LevelDebug: 7,
+	LevelInfo:  6,
+	LevelWarn:  4,
+	LevelError: 3,
+}
+
+// syslogFacilityUser is the RFC 5424 "user-level messages" facility
+const syslogFacilityUser = 1
+
+// write emits the encoded record r, a single write per record
+func (o *output) write(r *record, buf []byte) error {
+	if o.w == nil {
+		log.Print(string(buf))
+		return nil
+	}
+
+	var b bytes.Buffer
+	if o.syslog {
+		// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+		fmt.Fprintf(&b, "<%d>1 %s %s %s %d - - ", syslogFacilityUser*8+syslogSeverity[r.level],
+			r.time.Format(time.RFC3339Nano), o.host, o.app, os.Getpid())
+	} else if o.stamp {
+		b.WriteString(r.time.Format("2006/01/02 15:04:05 "))
+	}
+	b.Write(buf)
+	b.WriteByte('\n')
+	_, err := o.w.Write(b.Bytes())
+
+	return err
+}
+
+// close closes the output, if opened by openOutput()
+func (o *output) close() error {
+	if o.closer == nil {
+		return nil
+	}
+
+	return o.closer.Close()
+}
@@ -0,0 +1,208 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package debug
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSpec(t *testing.T) {
+	c, err := ParseSpec("crypto/masterKey.go, all,level=info,format=json,output=syslog:/tmp/x,")
+	assert.NoError(t, err)
+	assert.Equal(t, &Config{
+		Scope:  []string{"crypto/masterKey.go", "all"},
+		Level:  "info",
+		Format: "json",
+		Output: "syslog:/tmp/x",
+	}, c)
+
+	_, err = ParseSpec("all,colour=red")
+	assert.Equal(t, ErrSpecInvalid, err)
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, l := range []Level{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		got, err := ParseLevel(l.String())
+		assert.NoError(t, err)
+		assert.Equal(t, l, got)
+	}
+
+	got, err := ParseLevel("")
+	assert.NoError(t, err)
+	assert.Equal(t, LevelDebug, got)
+
+	_, err = ParseLevel("loud")
+	assert.Equal(t, ErrLevelInvalid, err)
+}
+
+func testRecord() *record {
+	return &record{
+		time:   time.Date(2017, 10, 1, 12, 0, 0, 0, time.UTC),
+		level:  LevelInfo,
+		file:   "crypto/masterKey.go",
+		line:   42,
+		msg:    "siteKey",
+		fields: []interface{}{"siteName", "example.com", "siteCounter", uint32(1), "keyContext", "", "password", Secret(secret)},
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	defer SetRedaction(RedactFull)
+
+	expect := `[INFO] siteKey siteName=example.com siteCounter=1 keyContext="" password=[REDACTED]`
+	assert.Equal(t, expect, string(encodeText(testRecord())))
+}
+
+func TestEncodeJSON(t *testing.T) {
+	defer SetRedaction(RedactFull)
+
+	expect := `{"time":"2017-10-01T12:00:00Z","level":"info","caller":"crypto/masterKey.go:42","msg":"siteKey",` +
+		`"siteName":"example.com","siteCounter":1,"keyContext":"","password":"[REDACTED]"}`
+	assert.Equal(t, expect, string(encodeJSON(testRecord())))
+
+	SetRedaction(RedactID)
+	assert.Contains(t, string(encodeJSON(testRecord())), `"password":"`+secretID+`"`)
+}
+
+func TestOpenOutput(t *testing.T) {
+	for _, spec := range []string{"fd:x", "syslogx", "syslog:fd:-1"} {
+		_, err := openOutput(spec)
+		assert.Equal(t, ErrOutputInvalid, err, spec)
+	}
+}
+
+func TestSyslogOutput(t *testing.T) {
+	var b bytes.Buffer
+	o := &output{w: &b, syslog: true, app: "gompw", host: "localhost"}
+
+	assert.NoError(t, o.write(testRecord(), []byte("msg")))
+	expect := "<14>1 2017-10-01T12:00:00Z localhost gompw " + strconv.Itoa(os.Getpid()) + " - - msg\n"
+	assert.Equal(t, expect, b.String())
+}
+
+func TestConfigure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trace := filepath.Join(dir, "gompw.trace")
+
+	d := &Debug{encode: encodeText, out: &output{}}
+	assert.Equal(t, ErrLevelInvalid, d.Configure(&Config{Level: "loud"}))
+	assert.Equal(t, ErrFormatInvalid, d.Configure(&Config{Format: "xml"}))
+	assert.False(t, d.enabled)
+
+	assert.NoError(t, d.Configure(&Config{Scope: []string{"debug/sink_internal_test.go"}, Level: "info", Output: trace}))
+	d.Log(LevelDebug, "filtered")
+	d.Log(LevelInfo, "wanted", "key", "value")
+	d.Log(LevelWarn, "odd", "key")
+	d.Dbg("filtered")
+	assert.NoError(t, d.Configure(&Config{Scope: []string{"crypto/masterKey.go"}, Output: trace, Format: FormatJSON}))
+	d.Log(LevelError, "out of scope")
+	assert.NoError(t, d.out.close())
+
+	buf, err := ioutil.ReadFile(trace)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasSuffix(lines[0], " [INFO] wanted key=value"), lines[0])
+		assert.True(t, strings.HasSuffix(lines[1], " [WARN] odd key=(MISSING)"), lines[1])
+	}
+
+	fi, err := os.Stat(trace)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
+
+// logWrapper is a wrapper as e.g. gompw's debug()
+func logWrapper(d *Debug, msg string) {
+	d.LogDepth(1, LevelInfo, msg)
+}
+
+func TestLogDepth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trace := filepath.Join(dir, "gompw.trace")
+
+	d := &Debug{encode: encodeText, out: &output{}}
+	assert.NoError(t, d.Configure(&Config{Scope: []string{"debug/sink_internal_test.go"}, Format: FormatJSON, Output: trace}))
+	_, _, line, _ := runtime.Caller(0)
+	logWrapper(d, "wrapped")
+	assert.NoError(t, d.Configure(&Config{Scope: []string{"debug/sink.go"}, Output: trace}))
+	logWrapper(d, "out of scope")
+	assert.NoError(t, d.out.close())
+
+	buf, err := ioutil.ReadFile(trace)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n")
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], `"caller":"debug/sink_internal_test.go:`+strconv.Itoa(line+1)+`","msg":"wrapped"`)
+	}
+}
+
+// TestConfigureConcurrent tests reconfiguring while logging, run with -race
+func TestConfigureConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "debug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trace := filepath.Join(dir, "gompw.trace")
+
+	d := &Debug{encode: encodeText, out: &output{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				d.Log(LevelInfo, "concurrent", "j", j)
+				d.Dbg("concurrent %d", j)
+			}
+		}()
+	}
+	for _, level := range []string{"debug", "error", "info"} {
+		assert.NoError(t, d.Configure(&Config{Level: level, Output: trace}))
+	}
+	wg.Wait()
+	assert.NoError(t, d.out.close())
+}
+
+func TestShortFile(t *testing.T) {
+	assert.Equal(t, "crypto/masterKey.go", shortFile("/go/src/pkg/crypto/masterKey.go"))
+	assert.Equal(t, "/masterKey.go", shortFile("/masterKey.go"))
+	assert.Equal(t, "masterKey.go", shortFile("masterKey.go"))
+}
@@ -0,0 +1,131 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package gitcredential
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// gitcredential exported errors
+var (
+	ErrAttributeInvalid = errors.New("Invalid git credential attribute, expected key=value")
+	ErrHostEmpty        = errors.New("git credential is missing the host")
+)
+
+// Credential holds the attributes of git's credential helper protocol, see gitcredentials(7)
+type Credential struct {
+	Protocol string
+	Host     string // may include the port
+	Path     string // only sent with credential.useHttpPath
+	Username string
+	Password string
+}
+
+// Read parses the key=value lines sent by git, up to a blank line or EOF.
+//
+//   Unknown attributes are ignored, a url attribute is decomposed.
+func Read(r io.Reader) (*Credential, error) {
+	c := &Credential{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrAttributeInvalid
+		}
+
+		switch kv[0] {
+		case "protocol":
+			c.Protocol = kv[1]
+		case "host":
+			c.Host = kv[1]
+		case "path":
+			c.Path = kv[1]
+		case "username":
+			c.Username = kv[1]
+		case "password":
+			c.Password = kv[1]
+		case "url":
+			if err := c.setURL(kv[1]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return c, scanner.Err()
+}
+
+// setURL sets the attributes from a url, as accepted by 'git credential fill'
+func (c *Credential) setURL(rawurl string) error {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return err
+	}
+
+	c.Protocol = u.Scheme
+	c.Host = u.Host
+	c.Path = strings.TrimPrefix(u.Path, "/")
+	if u.User != nil {
+		c.Username = u.User.Username()
+		c.Password, _ = u.User.Password()
+	}
+
+	return nil
+}
+
+// Write outputs the username and password attributes, as expected from a 'get'
+func (c *Credential) Write(w io.Writer) error {
+	if c.Username != "" {
+		if _, err := fmt.Fprintf(w, "username=%s\n", c.Username); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "password=%s\n", c.Password)
+
+	return err
+}
+
+// Sites returns the candidate site names, most specific first: host/path, host[:port], host
+func (c *Credential) Sites() ([]string, error) {
+	if c.Host == "" {
+		return nil, ErrHostEmpty
+	}
+
+	var sites []string
+	if c.Path != "" {
+		sites = append(sites, c.Host+"/"+strings.Trim(c.Path, "/"))
+	}
+	sites = append(sites, c.Host)
+	if host, _, err := net.SplitHostPort(c.Host); err == nil {
+		sites = append(sites, host)
+	}
+
+	return sites, nil
+}
@@ -0,0 +1,162 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// Package otp implements the HOTP (RFC 4226) and TOTP (RFC 6238) one-time password algorithms.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP defaults, as used by most authenticator apps
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+)
+
+// otp exported errors
+var (
+	ErrDigitsInvalid = errors.New("OTP digits must be between 6 and 8")
+	ErrPeriodInvalid = errors.New("OTP period must be at least one second")
+	ErrSecretEmpty   = errors.New("OTP secret must be set")
+)
+
+// HOTP returns the RFC 4226 code for the given counter
+func HOTP(secret []byte, counter uint64, digits int) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrSecretEmpty
+	}
+	if digits < 6 || digits > 8 {
+		return "", ErrDigitsInvalid
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	h := hmac.New(sha1.New, secret)
+	h.Write(msg[:])
+	sum := h.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, code%mod), nil
+}
+
+// TOTP generates RFC 6238 codes.
+//
+//   Digits and Period use DefaultDigits and DefaultPeriod when unset.
+//   Now is time.Now when unset, tests may inject their own clock.
+type TOTP struct {
+	Secret []byte
+	Digits int
+	Period time.Duration
+	Now    func() time.Time
+}
+
+// Code returns the current code
+func (t *TOTP) Code() (string, error) {
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+
+	return t.CodeAt(now())
+}
+
+// CodeAt returns the code for the given time
+func (t *TOTP) CodeAt(tm time.Time) (string, error) {
+	period := t.period()
+	if period < time.Second {
+		return "", ErrPeriodInvalid
+	}
+
+	return HOTP(t.Secret, uint64(tm.Unix())/uint64(period/time.Second), t.digits())
+}
+
+// URI returns the otpauth:// enrollment URI, see:
+//
+//   https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func (t *TOTP) URI(issuer, account string) string {
+	v := url.Values{}
+	v.Set("period", fmt.Sprint(int64(t.period()/time.Second)))
+
+	return uri("totp", t.Secret, issuer, account, t.digits(), v)
+}
+
+// HOTPURI returns the otpauth:// enrollment URI of a counter based (HOTP) secret,
+// counter being the next counter the authenticator will use
+func HOTPURI(secret []byte, counter uint64, digits int, issuer, account string) string {
+	v := url.Values{}
+	v.Set("counter", fmt.Sprint(counter))
+
+	return uri("hotp", secret, issuer, account, digits, v)
+}
+
+func uri(kind string, secret []byte, issuer, account string, digits int, v url.Values) string {
+	label := pathEscape(account)
+	if issuer != "" {
+		label = pathEscape(issuer) + ":" + label
+	}
+	v.Set("secret", Base32(secret))
+	if issuer != "" {
+		v.Set("issuer", issuer)
+	}
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(digits))
+
+	return "otpauth://" + kind + "/" + label + "?" + v.Encode()
+}
+
+func (t *TOTP) digits() int {
+	if t.Digits == 0 {
+		return DefaultDigits
+	}
+	return t.Digits
+}
+
+func (t *TOTP) period() time.Duration {
+	if t.Period == 0 {
+		return DefaultPeriod
+	}
+	return t.Period
+}
+
+// Base32 returns the secret as unpadded base32, the format expected by authenticator apps
+func Base32(secret []byte) string {
+	return strings.TrimRight(base32.StdEncoding.EncodeToString(secret), "=")
+}
+
+// pathEscape escapes s for use within the URI's label (url.PathEscape requires go1.8)
+func pathEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
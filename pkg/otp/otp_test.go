@@ -0,0 +1,102 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package otp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/otp"
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 4226 Appendix D and RFC 6238 Appendix B (SHA1) share this secret
+var rfcSecret = []byte("12345678901234567890")
+
+func TestHOTP(t *testing.T) {
+	expectations := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, expect := range expectations {
+		code, err := otp.HOTP(rfcSecret, uint64(counter), 6)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, code, "counter=%d", counter)
+	}
+
+	_, err := otp.HOTP(rfcSecret, 0, 9)
+	assert.Equal(t, otp.ErrDigitsInvalid, err)
+	_, err = otp.HOTP(nil, 0, 6)
+	assert.Equal(t, otp.ErrSecretEmpty, err)
+}
+
+func TestTOTP(t *testing.T) {
+	expectations := []struct {
+		unix   int64
+		expect string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tv := range expectations {
+		now := time.Unix(tv.unix, 0)
+		totp := &otp.TOTP{Secret: rfcSecret, Digits: 8, Now: func() time.Time { return now }}
+		code, err := totp.Code()
+		assert.NoError(t, err)
+		assert.Equal(t, tv.expect, code, "time=%d", tv.unix)
+	}
+
+	// defaults: 6 digits, 30 second period
+	totp := &otp.TOTP{Secret: rfcSecret}
+	code, err := totp.CodeAt(time.Unix(59, 0))
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	totp.Period = time.Millisecond
+	_, err = totp.CodeAt(time.Unix(59, 0))
+	assert.Equal(t, otp.ErrPeriodInvalid, err)
+}
+
+func TestTOTPURI(t *testing.T) {
+	totp := &otp.TOTP{Secret: rfcSecret}
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", otp.Base32(rfcSecret))
+	assert.Equal(t,
+		"otpauth://totp/example.com:Robert%20Lee%20Mitchell?algorithm=SHA1&digits=6&issuer=example.com&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		totp.URI("example.com", "Robert Lee Mitchell"))
+	assert.Equal(t,
+		"otpauth://totp/rlm?algorithm=SHA1&digits=6&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		totp.URI("", "rlm"))
+}
+
+func TestHOTPURI(t *testing.T) {
+	assert.Equal(t,
+		"otpauth://hotp/example.com:rlm?algorithm=SHA1&counter=0&digits=6&issuer=example.com&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		otp.HOTPURI(rfcSecret, 0, otp.DefaultDigits, "example.com", "rlm"))
+	assert.Equal(t,
+		"otpauth://hotp/rlm?algorithm=SHA1&counter=42&digits=8&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		otp.HOTPURI(rfcSecret, 42, 8, "", "rlm"))
+}
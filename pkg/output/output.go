@@ -0,0 +1,182 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Output exported errors
+var (
+	ErrEnvValueInvalid = errors.New("Env output cannot represent values containing a newline")
+	ErrFormatInvalid   = errors.New("Output format is invalid, expected one of: json, yaml, shell, env, template=...")
+)
+
+// templatePrefix selects the template format, e.g. template='{{.Site}}: {{.Password}}'
+const templatePrefix = "template="
+
+// Record is the machine-readable result of a site password derivation
+type Record struct {
+	Fullname  string `json:"fullname"`
+	Site      string `json:"site"`
+	LoginName string `json:"loginName,omitempty"`
+	Counter   uint32 `json:"counter"`
+	Type      string `json:"type"`
+	Purpose   string `json:"purpose"`
+	Context   string `json:"context,omitempty"`
+	Algorithm uint32 `json:"algorithm"`
+	KeyID     string `json:"keyID"`
+	Password  string `json:"password"`
+}
+
+// field is a Record field in output order, name is used by yaml, env is used by shell and env
+type field struct {
+	name  string
+	env   string
+	value interface{}
+	omit  bool // omitempty
+}
+
+func (r *Record) fields() []field {
+	return []field{
+		{"fullname", "GOMPW_FULLNAME", r.Fullname, false},
+		{"site", "GOMPW_SITE", r.Site, false},
+		{"loginName", "GOMPW_LOGIN_NAME", r.LoginName, r.LoginName == ""},
+		{"counter", "GOMPW_COUNTER", r.Counter, false},
+		{"type", "GOMPW_TYPE", r.Type, false},
+		{"purpose", "GOMPW_PURPOSE", r.Purpose, false},
+		{"context", "GOMPW_CONTEXT", r.Context, r.Context == ""},
+		{"algorithm", "GOMPW_ALGORITHM", r.Algorithm, false},
+		{"keyID", "GOMPW_KEY_ID", r.KeyID, false},
+		{"password", "GOMPW_PASSWORD", r.Password, false},
+	}
+}
+
+// Formatter writes Records in a machine-readable format
+type Formatter interface {
+	Write(w io.Writer, r *Record) error
+}
+
+// FormatterFunc adapts a func to the Formatter interface
+type FormatterFunc func(w io.Writer, r *Record) error
+
+// Write calls f(w, r)
+func (f FormatterFunc) Write(w io.Writer, r *Record) error {
+	return f(w, r)
+}
+
+// New returns the Formatter for format: json, yaml, shell, env or template=TEXT
+//
+//   TEXT is a text/template executed against the Record, with the shellquote function available.
+func New(format string) (Formatter, error) {
+	switch format {
+	case "json":
+		return FormatterFunc(writeJSON), nil
+	case "yaml":
+		return FormatterFunc(writeYAML), nil
+	case "shell":
+		return FormatterFunc(writeShell), nil
+	case "env":
+		return FormatterFunc(writeEnv), nil
+	}
+
+	if !strings.HasPrefix(format, templatePrefix) {
+		return nil, ErrFormatInvalid
+	}
+	text := strings.TrimPrefix(format, templatePrefix)
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	t, err := template.New("output").Funcs(template.FuncMap{"shellquote": ShellQuote}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return FormatterFunc(func(w io.Writer, r *Record) error {
+		return t.Execute(w, r)
+	}), nil
+}
+
+// ShellQuote single quotes s for POSIX shells, making it safe for eval
+func ShellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// writeJSON outputs a single line JSON object
+func writeJSON(w io.Writer, r *Record) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// writeYAML outputs a YAML document, strings are double quoted using JSON escaping (a subset of YAML's)
+func writeYAML(w io.Writer, r *Record) error {
+	if _, err := fmt.Fprintln(w, "---"); err != nil {
+		return err
+	}
+	for _, f := range r.fields() {
+		if f.omit {
+			continue
+		}
+		value := fmt.Sprint(f.value)
+		if s, ok := f.value.(string); ok {
+			b, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			value = string(b)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", f.name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeShell outputs single quoted variable assignments, for use with eval
+func writeShell(w io.Writer, r *Record) error {
+	for _, f := range r.fields() {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", f.env, ShellQuote(fmt.Sprint(f.value))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeEnv outputs unquoted KEY=value lines, as expected by env files (e.g. docker --env-file)
+func writeEnv(w io.Writer, r *Record) error {
+	for _, f := range r.fields() {
+		value := fmt.Sprint(f.value)
+		if strings.ContainsAny(value, "\r\n") {
+			return ErrEnvValueInvalid
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", f.env, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package output_test
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/output"
+	"github.com/stretchr/testify/assert"
+)
+
+var rlm = &output.Record{
+	Fullname:  "Robert Lee Mitchell",
+	Site:      "masterpasswordapp.com",
+	Counter:   1,
+	Type:      "long",
+	Purpose:   "auth",
+	Algorithm: 3,
+	KeyID:     "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302",
+	Password:  "Jejr5[RepuSosp",
+}
+
+func format(t *testing.T, f string, r *output.Record) string {
+	formatter, err := output.New(f)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, formatter.Write(&buf, r))
+
+	return buf.String()
+}
+
+func TestFormats(t *testing.T) {
+	assert.Equal(t, `{"fullname":"Robert Lee Mitchell","site":"masterpasswordapp.com","counter":1,"type":"long","purpose":"auth","algorithm":3,"keyID":"98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302","password":"Jejr5[RepuSosp"}
+`, format(t, "json", rlm))
+
+	assert.Equal(t, `---
+fullname: "Robert Lee Mitchell"
+site: "masterpasswordapp.com"
+counter: 1
+type: "long"
+purpose: "auth"
+algorithm: 3
+keyID: "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302"
+password: "Jejr5[RepuSosp"
+`, format(t, "yaml", rlm))
+
+	assert.Equal(t, `GOMPW_FULLNAME=Robert Lee Mitchell
+GOMPW_SITE=masterpasswordapp.com
+GOMPW_LOGIN_NAME=
+GOMPW_COUNTER=1
+GOMPW_TYPE=long
+GOMPW_PURPOSE=auth
+GOMPW_CONTEXT=
+GOMPW_ALGORITHM=3
+GOMPW_KEY_ID=98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302
+GOMPW_PASSWORD=Jejr5[RepuSosp
+`, format(t, "env", rlm))
+
+	assert.Equal(t, "masterpasswordapp.com: 'Jejr5[RepuSosp'\n", format(t, "template={{.Site}}: {{shellquote .Password}}", rlm))
+
+	_, err := output.New("xml")
+	assert.Equal(t, output.ErrFormatInvalid, err)
+	_, err = output.New("template={{.Site")
+	assert.Error(t, err)
+	_, err = output.New("template={{.Bogus}}")
+	assert.NoError(t, err)
+}
+
+func TestShellEval(t *testing.T) {
+	r := *rlm
+	r.Password = `it's "$HOME" \` + "`id`" + ` ;$(id)`
+	r.Context = "mother's maiden\nname"
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	script := format(t, "shell", &r) + `printf '%s|%s' "$GOMPW_PASSWORD" "$GOMPW_CONTEXT"`
+	out, err := exec.Command(sh, "-c", script).Output()
+	assert.NoError(t, err)
+	assert.Equal(t, r.Password+"|"+r.Context, string(out))
+
+	formatter, _ := output.New("env")
+	assert.Equal(t, output.ErrEnvValueInvalid, formatter.Write(&bytes.Buffer{}, &r))
+}
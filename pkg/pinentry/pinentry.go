@@ -0,0 +1,213 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package pinentry
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Pinentry exported errors
+var (
+	ErrCanceled = errors.New("Pinentry was canceled")
+	ErrProtocol = errors.New("Pinentry protocol error")
+)
+
+// errCanceled is the gpg-error code of GPG_ERR_CANCELED (within the pinentry source)
+const errCanceled = 83886179
+
+// Client talks the Assuan protocol to a pinentry program
+type Client struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+	out *bufio.Reader
+}
+
+// New starts the pinentry program, passing on GPG_TTY/TERM so curses pinentries find the terminal
+func New(program string, args ...string) (*Client, error) {
+	cmd := exec.Command(program, args...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	c := &Client{cmd: cmd, in: in, out: bufio.NewReader(out)}
+	// greeting
+	if _, err = c.response(); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	// optional, not all pinentries support them
+	if tty := os.Getenv("GPG_TTY"); tty != "" {
+		c.command("OPTION ttyname=" + tty)
+	}
+	if term := os.Getenv("TERM"); term != "" {
+		c.command("OPTION ttytype=" + term)
+	}
+
+	return c, nil
+}
+
+// SetTitle sets the window title
+func (c *Client) SetTitle(title string) error {
+	_, err := c.command("SETTITLE " + escape(title))
+	return err
+}
+
+// SetDesc sets the descriptive text
+func (c *Client) SetDesc(desc string) error {
+	_, err := c.command("SETDESC " + escape(desc))
+	return err
+}
+
+// SetPrompt sets the text in front of the input field
+func (c *Client) SetPrompt(prompt string) error {
+	_, err := c.command("SETPROMPT " + escape(prompt))
+	return err
+}
+
+// SetError shows an error, e.g. when asking again after a mistyped password
+func (c *Client) SetError(msg string) error {
+	_, err := c.command("SETERROR " + escape(msg))
+	return err
+}
+
+// GetPin asks the user for the PIN (or password), ErrCanceled is returned if the user canceled
+func (c *Client) GetPin() (string, error) {
+	return c.command("GETPIN")
+}
+
+// Close ends the session and waits for the pinentry to exit
+func (c *Client) Close() error {
+	// the pinentry may already be gone
+	c.command("BYE")
+	c.in.Close()
+
+	return c.cmd.Wait()
+}
+
+// command sends cmd, returning the data of the response
+func (c *Client) command(cmd string) (string, error) {
+	if _, err := io.WriteString(c.in, cmd+"\n"); err != nil {
+		return "", err
+	}
+
+	return c.response()
+}
+
+// response reads up to the OK or ERR line, returning the decoded data (D) lines
+func (c *Client) response() (string, error) {
+	var data string
+	for {
+		line, err := c.out.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "OK" || strings.HasPrefix(line, "OK "):
+			return data, nil
+		case strings.HasPrefix(line, "ERR "):
+			return "", parseErr(line)
+		case strings.HasPrefix(line, "D "):
+			data += unescape(line[2:])
+		case strings.HasPrefix(line, "INQUIRE "):
+			// nothing to offer
+			if _, err = io.WriteString(c.in, "CAN\n"); err != nil {
+				return "", err
+			}
+		case strings.HasPrefix(line, "S "), strings.HasPrefix(line, "#"):
+			// status and comments
+		default:
+			return "", ErrProtocol
+		}
+	}
+}
+
+// parseErr converts an "ERR code description" line
+func parseErr(line string) error {
+	fields := strings.SplitN(line, " ", 3)
+	if code, err := strconv.Atoi(fields[1]); err == nil && code&0xFFFF == errCanceled&0xFFFF {
+		return ErrCanceled
+	}
+
+	return fmt.Errorf("pinentry: %s", strings.TrimPrefix(line, "ERR "))
+}
+
+// escape percent-encodes the characters Assuan requires to be escaped
+func escape(s string) string {
+	r := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	return r.Replace(s)
+}
+
+// unescape decodes the percent-encoded data lines
+func unescape(s string) string {
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if b, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				buf = append(buf, byte(b))
+				i += 2
+				continue
+			}
+		}
+		buf = append(buf, s[i])
+	}
+
+	return string(buf)
+}
+
+// GetPassword is a convenience wrapper running a single pinentry session
+func GetPassword(program, title, desc, prompt string) (string, error) {
+	c, err := New(program)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	if err = c.SetTitle(title); err != nil {
+		return "", err
+	}
+	if err = c.SetDesc(desc); err != nil {
+		return "", err
+	}
+	if err = c.SetPrompt(prompt); err != nil {
+		return "", err
+	}
+
+	return c.GetPin()
+}
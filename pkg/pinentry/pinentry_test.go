@@ -0,0 +1,68 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package pinentry_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/pinentry"
+	"github.com/stretchr/testify/assert"
+)
+
+const fakePinentry = "../../files/fake-pinentry"
+
+func TestGetPassword(t *testing.T) {
+	log, err := ioutil.TempFile("", "gompw-pinentry-")
+	if !assert.NoError(t, err) {
+		return
+	}
+	log.Close()
+	defer os.Remove(log.Name())
+
+	os.Setenv("FAKE_PINENTRY_LOG", log.Name())
+	os.Setenv("FAKE_PINENTRY_PIN", "banana%25colored%0Aduckling")
+	os.Setenv("GPG_TTY", "/dev/pts/9")
+	defer os.Unsetenv("FAKE_PINENTRY_LOG")
+	defer os.Unsetenv("FAKE_PINENTRY_PIN")
+	defer os.Unsetenv("GPG_TTY")
+
+	pw, err := pinentry.GetPassword(fakePinentry, "gompw", "Master password for\nRobert Lee Mitchell (100%)", "Password:")
+	assert.NoError(t, err)
+	assert.Equal(t, "banana%colored\nduckling", pw)
+
+	b, err := ioutil.ReadFile(log.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "OPTION ttyname=/dev/pts/9\n")
+	assert.Contains(t, string(b), "SETTITLE gompw\nSETDESC Master password for%0ARobert Lee Mitchell (100%25)\nSETPROMPT Password:\nGETPIN\nBYE\n")
+}
+
+func TestGetPasswordCanceled(t *testing.T) {
+	os.Setenv("FAKE_PINENTRY_CANCEL", "1")
+	defer os.Unsetenv("FAKE_PINENTRY_CANCEL")
+
+	_, err := pinentry.GetPassword(fakePinentry, "gompw", "desc", "prompt")
+	assert.Equal(t, pinentry.ErrCanceled, err)
+
+	_, err = pinentry.New("/nonexistent/pinentry")
+	assert.Error(t, err)
+}
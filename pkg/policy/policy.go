@@ -0,0 +1,248 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// Package policy describes the password constraints of a site, e.g. those enforced by its sign up form.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Character classes
+const (
+	ClassDigit  = "digit"
+	ClassLower  = "lower"
+	ClassSymbol = "symbol"
+	ClassUpper  = "upper"
+)
+
+// Classes maps the character classes to their (printable ASCII) characters, a space belongs to none
+var Classes = map[string]string{
+	ClassDigit:  "0123456789",
+	ClassLower:  "abcdefghijklmnopqrstuvwxyz",
+	ClassSymbol: "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+	ClassUpper:  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+}
+
+// policy exported errors
+var (
+	ErrClassMissing  = errors.New("Password is missing a required character class")
+	ErrClassUnknown  = errors.New("Policy character class must be one of: digit, lower, symbol, upper")
+	ErrForbidden     = errors.New("Password contains a forbidden character")
+	ErrLengthInvalid = errors.New("Policy minLength must not exceed maxLength")
+	ErrSpecInvalid   = errors.New("Policy spec must be of the form: min=N,max=N,require=class+class,forbid=CHARS")
+	ErrTooLong       = errors.New("Password is longer than the policy's maxLength")
+	ErrTooShort      = errors.New("Password is shorter than the policy's minLength")
+	ErrUnsatisfiable = errors.New("Policy cannot be satisfied, as its required classes are forbidden or exceed maxLength")
+)
+
+// Policy is a site's password constraints, the zero value allows any password.
+//
+//   [sites."example.com".policy]
+//   minLength = 12
+//   maxLength = 16
+//   require = ["digit", "symbol"]
+//   forbid = "';"
+type Policy struct {
+	Require   []string `toml:"require,omitempty" json:"require,omitempty"`     // Class*
+	Forbid    string   `toml:"forbid,omitempty" json:"forbid,omitempty"`       // forbidden characters
+	MinLength uint32   `toml:"minLength,omitempty" json:"minLength,omitempty"` // 0 == unbounded
+	MaxLength uint32   `toml:"maxLength,omitempty" json:"maxLength,omitempty"` // 0 == unbounded
+}
+
+// Pattern describes every password that a password type may generate
+type Pattern struct {
+	Chars      string   // all of the characters that may occur
+	Guarantees []string // the classes that always occur
+	MinLength  int
+	MaxLength  int
+}
+
+// Parse returns the Policy described by spec, e.g. for --policy
+//
+//   min=12,max=16,require=digit+symbol,forbid=';
+//
+//   NOTE: forbid must be last, as it takes the remainder of spec verbatim, including any commas
+func Parse(spec string) (*Policy, error) {
+	p := &Policy{}
+	for spec != "" {
+		var field string
+		if strings.HasPrefix(spec, "forbid=") {
+			field, spec = spec, ""
+		} else if i := strings.IndexByte(spec, ','); i >= 0 {
+			field, spec = spec[:i], spec[i+1:]
+		} else {
+			field, spec = spec, ""
+		}
+
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return nil, ErrSpecInvalid
+		}
+		var err error
+		switch kv[0] {
+		case "min":
+			p.MinLength, err = parseLength(kv[1])
+		case "max":
+			p.MaxLength, err = parseLength(kv[1])
+		case "require":
+			p.Require = strings.Split(kv[1], "+")
+		case "forbid":
+			p.Forbid = kv[1]
+		default:
+			err = ErrSpecInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, p.Validate()
+}
+
+func parseLength(s string) (uint32, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, ErrSpecInvalid
+	}
+
+	return uint32(n), nil
+}
+
+// String returns the policy as a Parse() spec
+func (p *Policy) String() string {
+	if p == nil {
+		return ""
+	}
+	var fields []string
+	if p.MinLength != 0 {
+		fields = append(fields, fmt.Sprintf("min=%d", p.MinLength))
+	}
+	if p.MaxLength != 0 {
+		fields = append(fields, fmt.Sprintf("max=%d", p.MaxLength))
+	}
+	if len(p.Require) != 0 {
+		fields = append(fields, "require="+strings.Join(p.Require, "+"))
+	}
+	if p.Forbid != "" {
+		fields = append(fields, "forbid="+p.Forbid)
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// Validate checks that the policy is consistent and can be satisfied
+func (p *Policy) Validate() error {
+	if p.MaxLength != 0 && p.MinLength > p.MaxLength {
+		return ErrLengthInvalid
+	}
+	for _, class := range p.Require {
+		chars, ok := Classes[class]
+		if !ok {
+			return ErrClassUnknown
+		}
+		if p.Allowed(chars) == "" {
+			return ErrUnsatisfiable
+		}
+	}
+	if p.MaxLength != 0 && uint32(len(p.Require)) > p.MaxLength {
+		return ErrUnsatisfiable
+	}
+
+	return nil
+}
+
+// Allowed returns chars without the forbidden characters
+func (p *Policy) Allowed(chars string) string {
+	if p.Forbid == "" {
+		return chars
+	}
+
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(p.Forbid, r) {
+			return -1
+		}
+		return r
+	}, chars)
+}
+
+// Check reports the first policy violation of password, if any
+func (p *Policy) Check(password string) error {
+	if p.MinLength != 0 && uint32(len(password)) < p.MinLength {
+		return ErrTooShort
+	}
+	if p.MaxLength != 0 && uint32(len(password)) > p.MaxLength {
+		return ErrTooLong
+	}
+	if strings.ContainsAny(password, p.Forbid) {
+		return ErrForbidden
+	}
+	for _, class := range p.Require {
+		if !strings.ContainsAny(password, Classes[class]) {
+			return ErrClassMissing
+		}
+	}
+
+	return nil
+}
+
+// CheckPattern reports the first policy violation that any password matching pt may have, if any
+func (p *Policy) CheckPattern(pt *Pattern) error {
+	if p.MinLength != 0 && pt.MinLength < int(p.MinLength) {
+		return ErrTooShort
+	}
+	if p.MaxLength != 0 && pt.MaxLength > int(p.MaxLength) {
+		return ErrTooLong
+	}
+	if strings.ContainsAny(pt.Chars, p.Forbid) {
+		return ErrForbidden
+	}
+	for _, class := range p.Require {
+		found := false
+		for _, guarantee := range pt.Guarantees {
+			if guarantee == class {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrClassMissing
+		}
+	}
+
+	return nil
+}
+
+// ClassOf returns the class that all of chars belong to, "" if none
+func ClassOf(chars string) string {
+	if chars == "" {
+		return ""
+	}
+	for class, members := range Classes {
+		if strings.Trim(chars, members) == "" {
+			return class
+		}
+	}
+
+	return ""
+}
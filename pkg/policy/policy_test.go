@@ -0,0 +1,103 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	p, err := policy.Parse("min=12,max=16,require=digit+symbol,forbid=';, ")
+	assert.NoError(t, err)
+	assert.Equal(t, &policy.Policy{MinLength: 12, MaxLength: 16, Require: []string{"digit", "symbol"}, Forbid: "';, "}, p)
+	assert.Equal(t, "min=12,max=16,require=digit+symbol,forbid=';, ", p.String())
+
+	p, err = policy.Parse("")
+	assert.NoError(t, err)
+	assert.Equal(t, &policy.Policy{}, p)
+
+	expectations := []struct {
+		spec string
+		err  error
+	}{
+		{"min=12,max=8", policy.ErrLengthInvalid},
+		{"min=x", policy.ErrSpecInvalid},
+		{"min", policy.ErrSpecInvalid},
+		{"length=12", policy.ErrSpecInvalid},
+		{"require=digit+emoji", policy.ErrClassUnknown},
+		{"require=digit,forbid=0123456789", policy.ErrUnsatisfiable},
+		{"max=1,require=digit+upper", policy.ErrUnsatisfiable},
+	}
+	for _, tv := range expectations {
+		_, err = policy.Parse(tv.spec)
+		assert.Equal(t, tv.err, err, tv.spec)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := &policy.Policy{MinLength: 8, MaxLength: 12, Require: []string{"upper", "digit"}, Forbid: "';"}
+
+	expectations := []struct {
+		pw  string
+		err error
+	}{
+		{"Abcdefg1", nil},
+		{"Abcdef1", policy.ErrTooShort},
+		{"Abcdefghijk12", policy.ErrTooLong},
+		{"Abcdefg1;", policy.ErrForbidden},
+		{"abcdefg1", policy.ErrClassMissing},
+		{"Abcdefgh", policy.ErrClassMissing},
+	}
+	for _, tv := range expectations {
+		assert.Equal(t, tv.err, p.Check(tv.pw), tv.pw)
+	}
+
+	assert.NoError(t, (&policy.Policy{}).Check(""))
+}
+
+func TestCheckPattern(t *testing.T) {
+	p := &policy.Policy{MinLength: 8, MaxLength: 12, Require: []string{"digit"}, Forbid: "'"}
+	pt := &policy.Pattern{Chars: "abc123", Guarantees: []string{"digit"}, MinLength: 8, MaxLength: 12}
+	assert.NoError(t, p.CheckPattern(pt))
+
+	pt.MinLength = 7
+	assert.Equal(t, policy.ErrTooShort, p.CheckPattern(pt))
+	pt.MinLength, pt.MaxLength = 8, 13
+	assert.Equal(t, policy.ErrTooLong, p.CheckPattern(pt))
+	pt.MaxLength, pt.Chars = 12, "abc'"
+	assert.Equal(t, policy.ErrForbidden, p.CheckPattern(pt))
+	pt.Chars, pt.Guarantees = "abc123", []string{"lower"}
+	assert.Equal(t, policy.ErrClassMissing, p.CheckPattern(pt))
+}
+
+func TestClassOf(t *testing.T) {
+	assert.Equal(t, "digit", policy.ClassOf("0123456789"))
+	assert.Equal(t, "upper", policy.ClassOf("AEIOU"))
+	assert.Equal(t, "symbol", policy.ClassOf("@&%?,=[]_:-+*$#!'^~;()/."))
+	assert.Equal(t, "", policy.ClassOf(" "))
+	assert.Equal(t, "", policy.ClassOf("aA"))
+	assert.Equal(t, "", policy.ClassOf(""))
+
+	assert.Equal(t, "abc", (&policy.Policy{Forbid: "';"}).Allowed("a'b;c"))
+}
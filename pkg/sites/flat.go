@@ -0,0 +1,276 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package sites
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const flatHeader = `# Master Password site export
+#     %s
+# 
+##
+# Format: %d
+# Date: %s
+# User Name: %s
+# Full Name: %s
+# Avatar: %d
+# Key ID: %s
+# Version: %s
+# Algorithm: %d
+# Default Type: %d
+# Passwords: %s
+##
+#
+#               Last     Times  Password                      Login	                     Site	Site
+#               used      used      type                       name	                     name	password
+`
+
+// flatSite mimics the mpw printf() format for format 1 site lines
+const flatSite = "%s  %8d  %d:%d:%10d  %25s\t%25s\t%s\n"
+
+// ReadFlat parses the flat .mpsites format (format 0 & 1)
+func ReadFlat(r io.Reader) (*User, error) {
+	u := &User{}
+	format := -1
+	inHeader, headerDone := false, false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// the header is delimited by '##' lines
+		if strings.HasPrefix(line, "##") {
+			if inHeader {
+				headerDone = true
+			}
+			inHeader = !inHeader && !headerDone
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if inHeader {
+				if err := u.parseFlatHeader(line, &format); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		if !headerDone {
+			return nil, ErrMalformed
+		}
+
+		s, err := parseFlatSite(line, format)
+		if err != nil {
+			return nil, err
+		}
+		if s.Algorithm == 0 && format == 0 {
+			s.Algorithm = u.Algorithm
+		}
+		u.Sites = append(u.Sites, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !headerDone {
+		return nil, ErrMalformed
+	}
+
+	u.sortSites()
+
+	return u, nil
+}
+
+func (u *User) parseFlatHeader(line string, format *int) error {
+	kv := strings.SplitN(strings.TrimPrefix(line, "#"), ":", 2)
+	if len(kv) != 2 {
+		return nil
+	}
+	key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+	var err error
+	switch key {
+	case "Format":
+		*format, err = strconv.Atoi(value)
+		if err == nil && *format != 0 && *format != 1 {
+			return ErrFormatUnsupported
+		}
+	case "Date":
+		u.ExportDate, err = parseDate(value)
+	case "User Name":
+		// format 0 only had 'User Name'
+		if u.Fullname == "" {
+			u.Fullname = value
+		}
+	case "Full Name":
+		u.Fullname = value
+	case "Avatar":
+		u.Avatar, err = parseUint32(value)
+	case "Key ID":
+		u.KeyID = value
+	case "Version":
+		u.Version = value
+	case "Algorithm":
+		u.Algorithm, err = parseUint32(value)
+	case "Default Type":
+		var rt uint32
+		rt, err = parseUint32(value)
+		u.DefaultType = ResultType(rt)
+	case "Passwords":
+		u.Redacted = value == "PROTECTED"
+	}
+	if err != nil {
+		return fmt.Errorf("%s: header '%s': %s", ErrMalformed, key, err)
+	}
+
+	return nil
+}
+
+// parseFlatSite parses a site line:
+//
+//   format 0: lastUsed uses type:algorithm siteName\tcontent
+//   format 1: lastUsed uses type:algorithm:counter loginName\tsiteName\tcontent
+func parseFlatSite(line string, format int) (*Site, error) {
+	var err error
+	var lastUsed, uses, typeAlgCounter string
+
+	rest := line
+	lastUsed, rest = nextToken(rest)
+	uses, rest = nextToken(rest)
+	typeAlgCounter, rest = nextTypeToken(rest, format+1)
+
+	s := &Site{Counter: 1}
+	if s.LastUsed, err = parseDate(lastUsed); err != nil {
+		return nil, malformedSite(line, err)
+	}
+	if s.Uses, err = parseUint32(uses); err != nil {
+		return nil, malformedSite(line, err)
+	}
+
+	tac := strings.Split(typeAlgCounter, ":")
+	fields := strings.SplitN(rest, "\t", 3)
+	switch {
+	case format == 0 && len(tac) == 2 && len(fields) >= 1:
+		s.Name = strings.TrimSpace(fields[0])
+		if len(fields) > 1 {
+			s.Content = strings.Join(fields[1:], "\t")
+		}
+	case format == 1 && len(tac) == 3 && len(fields) >= 2:
+		s.LoginName = strings.TrimSpace(fields[0])
+		s.Name = strings.TrimSpace(fields[1])
+		if len(fields) > 2 {
+			s.Content = fields[2]
+		}
+		if s.Counter, err = parseUint32(tac[2]); err != nil {
+			return nil, malformedSite(line, err)
+		}
+	default:
+		return nil, malformedSite(line, nil)
+	}
+
+	var rt uint32
+	if rt, err = parseUint32(tac[0]); err != nil {
+		return nil, malformedSite(line, err)
+	}
+	s.Type = ResultType(rt)
+	if s.Algorithm, err = parseUint32(tac[1]); err != nil {
+		return nil, malformedSite(line, err)
+	}
+
+	if s.Name == "" {
+		return nil, malformedSite(line, ErrSiteNameEmpty)
+	}
+
+	return s, nil
+}
+
+// WriteFlat writes u in the flat .mpsites format (format 1)
+func WriteFlat(w io.Writer, u *User) error {
+	description := "Export of site names and passwords in clear-text."
+	passwords := "VISIBLE"
+	if u.Redacted {
+		description = "Export of site names and stored passwords (unless device-private) encrypted with the master key."
+		passwords = "PROTECTED"
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, flatHeader, description, 1, formatDate(u.ExportDate), u.Fullname, u.Fullname,
+		u.Avatar, u.KeyID, u.Version, u.Algorithm, u.DefaultType, passwords)
+
+	for _, s := range u.Sites {
+		fmt.Fprintf(bw, flatSite, formatDate(s.LastUsed), s.Uses, s.Type, s.Algorithm, s.Counter,
+			s.LoginName, s.Name, s.Content)
+	}
+
+	return bw.Flush()
+}
+
+func malformedSite(line string, err error) error {
+	if err != nil {
+		return fmt.Errorf("%s: site '%s': %s", ErrMalformed, line, err)
+	}
+
+	return fmt.Errorf("%s: site '%s'", ErrMalformed, line)
+}
+
+// nextToken returns the next space delimited token and the remainder of s
+func nextToken(s string) (string, string) {
+	s = strings.TrimLeft(s, " ")
+	i := strings.IndexAny(s, " \t")
+	if i < 0 {
+		return s, ""
+	}
+
+	return s[:i], strings.TrimLeft(s[i:], " ")
+}
+
+// nextTypeToken returns the next type:algorithm[:counter] token and the remainder of s.
+//
+// mpw pads the counter (%10lu), so spaces following a ':' are part of the token.
+func nextTypeToken(s string, colons int) (string, string) {
+	var tok string
+
+	s = strings.TrimLeft(s, " ")
+	for i := 0; i < colons; i++ {
+		j := strings.Index(s, ":")
+		if j < 0 {
+			break
+		}
+		tok += s[:j+1]
+		s = strings.TrimLeft(s[j+1:], " ")
+	}
+	last, rest := nextToken(s)
+
+	return tok + last, rest
+}
+
+func parseUint32(s string) (uint32, error) {
+	v, err := strconv.ParseUint(s, 10, 32)
+
+	return uint32(v), err
+}
@@ -0,0 +1,182 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package sites
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// jsonFile mirrors the mpw .mpsites.json layout
+type jsonFile struct {
+	Export jsonExport          `json:"export"`
+	User   jsonUser            `json:"user"`
+	Sites  map[string]jsonSite `json:"sites"`
+}
+
+type jsonExport struct {
+	Format   int    `json:"format"`
+	Redacted bool   `json:"redacted"`
+	Date     string `json:"date"`
+}
+
+type jsonUser struct {
+	Avatar      uint32     `json:"avatar"`
+	FullName    string     `json:"full_name"`
+	LastUsed    string     `json:"last_used"`
+	KeyID       string     `json:"key_id,omitempty"`
+	Algorithm   uint32     `json:"algorithm"`
+	DefaultType ResultType `json:"default_type"`
+}
+
+type jsonSite struct {
+	Type      ResultType              `json:"type"`
+	Counter   uint32                  `json:"counter"`
+	Algorithm uint32                  `json:"algorithm"`
+	Password  string                  `json:"password,omitempty"`
+	LoginName string                  `json:"login_name,omitempty"`
+	Uses      uint32                  `json:"uses"`
+	LastUsed  string                  `json:"last_used"`
+	Questions map[string]jsonQuestion `json:"questions,omitempty"`
+	Ext       *jsonSiteExt            `json:"_ext_mpw,omitempty"`
+}
+
+type jsonQuestion struct {
+	Answer string `json:"answer,omitempty"`
+}
+
+type jsonSiteExt struct {
+	URL string `json:"url,omitempty"`
+}
+
+// ReadJSON parses the .mpsites.json format
+func ReadJSON(r io.Reader) (*User, error) {
+	var jf jsonFile
+	var err error
+
+	if err = json.NewDecoder(r).Decode(&jf); err != nil {
+		return nil, err
+	}
+	if jf.Export.Format != FormatJSON {
+		return nil, ErrFormatUnsupported
+	}
+
+	u := &User{
+		Fullname:    jf.User.FullName,
+		KeyID:       jf.User.KeyID,
+		Avatar:      jf.User.Avatar,
+		Algorithm:   jf.User.Algorithm,
+		DefaultType: jf.User.DefaultType,
+		Redacted:    jf.Export.Redacted,
+	}
+	if u.ExportDate, err = parseDate(jf.Export.Date); err != nil {
+		return nil, err
+	}
+	if u.LastUsed, err = parseDate(jf.User.LastUsed); err != nil {
+		return nil, err
+	}
+
+	for name, js := range jf.Sites {
+		s := &Site{
+			Name:      name,
+			LoginName: js.LoginName,
+			Content:   js.Password,
+			Type:      js.Type,
+			Counter:   js.Counter,
+			Algorithm: js.Algorithm,
+			Uses:      js.Uses,
+		}
+		if s.LastUsed, err = parseDate(js.LastUsed); err != nil {
+			return nil, err
+		}
+		if js.Ext != nil {
+			s.URL = js.Ext.URL
+		}
+		for keyword, q := range js.Questions {
+			s.Questions = append(s.Questions, &Question{Keyword: keyword, Content: q.Answer})
+		}
+		sort.Sort(byKeyword(s.Questions))
+		u.Sites = append(u.Sites, s)
+	}
+	u.sortSites()
+
+	return u, nil
+}
+
+// WriteJSON writes u in the .mpsites.json format
+func WriteJSON(w io.Writer, u *User) error {
+	jf := jsonFile{
+		Export: jsonExport{
+			Format:   FormatJSON,
+			Redacted: u.Redacted,
+			Date:     formatDate(u.ExportDate),
+		},
+		User: jsonUser{
+			Avatar:      u.Avatar,
+			FullName:    u.Fullname,
+			LastUsed:    formatDate(u.LastUsed),
+			KeyID:       u.KeyID,
+			Algorithm:   u.Algorithm,
+			DefaultType: u.DefaultType,
+		},
+		Sites: make(map[string]jsonSite, len(u.Sites)),
+	}
+
+	for _, s := range u.Sites {
+		js := jsonSite{
+			Type:      s.Type,
+			Counter:   s.Counter,
+			Algorithm: s.Algorithm,
+			Password:  s.Content,
+			LoginName: s.LoginName,
+			Uses:      s.Uses,
+			LastUsed:  formatDate(s.LastUsed),
+		}
+		if s.URL != "" {
+			js.Ext = &jsonSiteExt{URL: s.URL}
+		}
+		if len(s.Questions) > 0 {
+			js.Questions = make(map[string]jsonQuestion, len(s.Questions))
+			for _, q := range s.Questions {
+				js.Questions[q.Keyword] = jsonQuestion{Answer: q.Content}
+			}
+		}
+		jf.Sites[s.Name] = js
+	}
+
+	// json.Marshal sorts the map keys, keeping the output stable
+	b, err := json.MarshalIndent(jf, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = w.Write(b)
+
+	return err
+}
+
+// byKeyword sorts questions by keyword
+type byKeyword []*Question
+
+func (q byKeyword) Len() int           { return len(q) }
+func (q byKeyword) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q byKeyword) Less(i, j int) bool { return q[i].Keyword < q[j].Keyword }
@@ -0,0 +1,126 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package sites
+
+import (
+	"errors"
+)
+
+// ResultType is the mpw numeric site type, as stored in the mpsites formats.
+type ResultType uint32
+
+// ResultType class bits
+const (
+	ResultTypeClassTemplate ResultType = 1 << 4
+	ResultTypeClassStateful ResultType = 1 << 5
+	ResultTypeClassDerive   ResultType = 1 << 6
+)
+
+// ResultTypes as defined by the mpw clients
+const (
+	ResultTypeTemplateMaximum ResultType = ResultTypeClassTemplate | 0x0
+	ResultTypeTemplateLong    ResultType = ResultTypeClassTemplate | 0x1
+	ResultTypeTemplateMedium  ResultType = ResultTypeClassTemplate | 0x2
+	ResultTypeTemplateShort   ResultType = ResultTypeClassTemplate | 0x3
+	ResultTypeTemplateBasic   ResultType = ResultTypeClassTemplate | 0x4
+	ResultTypeTemplatePIN     ResultType = ResultTypeClassTemplate | 0x5
+	ResultTypeTemplateName    ResultType = ResultTypeClassTemplate | 0xE
+	ResultTypeTemplatePhrase  ResultType = ResultTypeClassTemplate | 0xF
+
+	ResultTypeStatefulPersonal ResultType = ResultTypeClassStateful | 0x0 | 1<<10
+	ResultTypeStatefulDevice   ResultType = ResultTypeClassStateful | 0x1 | 1<<11
+	ResultTypeDeriveKey        ResultType = ResultTypeClassDerive | 0x0 | 1<<12
+)
+
+// ResultType exported errors
+var (
+	ErrResultTypeInvalid     = errors.New("mpsites site type is invalid")
+	ErrResultTypeNotTemplate = errors.New("mpsites site type is not a password template type")
+)
+
+var (
+	rtmap = map[ResultType]string{
+		ResultTypeTemplateMaximum: "maximum",
+		ResultTypeTemplateLong:    "long",
+		ResultTypeTemplateMedium:  "medium",
+		ResultTypeTemplateShort:   "short",
+		ResultTypeTemplateBasic:   "basic",
+		ResultTypeTemplatePIN:     "pin",
+		ResultTypeTemplateName:    "name",
+		ResultTypeTemplatePhrase:  "phrase",
+
+		ResultTypeStatefulPersonal: "personal",
+		ResultTypeStatefulDevice:   "device",
+		ResultTypeDeriveKey:        "key",
+	}
+
+	// includes the crypto shortcodes
+	ptmap = map[string]ResultType{
+		"x": ResultTypeTemplateMaximum, "maximum": ResultTypeTemplateMaximum,
+		"l": ResultTypeTemplateLong, "long": ResultTypeTemplateLong,
+		"m": ResultTypeTemplateMedium, "medium": ResultTypeTemplateMedium,
+		"s": ResultTypeTemplateShort, "short": ResultTypeTemplateShort,
+		"b": ResultTypeTemplateBasic, "basic": ResultTypeTemplateBasic,
+		"i": ResultTypeTemplatePIN, "pin": ResultTypeTemplatePIN,
+		"n": ResultTypeTemplateName, "name": ResultTypeTemplateName,
+		"p": ResultTypeTemplatePhrase, "phrase": ResultTypeTemplatePhrase,
+	}
+)
+
+func (rt ResultType) String() string {
+	return rtmap[rt]
+}
+
+// Validate will test if the ResultType is known
+func (rt ResultType) Validate() error {
+	if _, ok := rtmap[rt]; !ok {
+		return ErrResultTypeInvalid
+	}
+
+	return nil
+}
+
+// IsTemplate reports if the ResultType is derived from a password template
+func (rt ResultType) IsTemplate() bool {
+	return rt&ResultTypeClassTemplate != 0
+}
+
+// PasswordType returns the crypto password type for a template ResultType
+func (rt ResultType) PasswordType() (string, error) {
+	if err := rt.Validate(); err != nil {
+		return "", err
+	}
+	if !rt.IsTemplate() {
+		return "", ErrResultTypeNotTemplate
+	}
+
+	return rtmap[rt], nil
+}
+
+// PasswordTypeToResultType returns the ResultType of the given crypto password type
+func PasswordTypeToResultType(passwordType string) (ResultType, error) {
+	rt, ok := ptmap[passwordType]
+	if !ok {
+		return 0, ErrResultTypeNotTemplate
+	}
+
+	return rt, nil
+}
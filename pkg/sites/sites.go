@@ -0,0 +1,196 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+// Package sites reads and writes the Master Password site export formats.
+//
+//   .mpsites       flat text format (format 0 & 1)
+//   .mpsites.json  JSON format (format 1)
+package sites
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Export formats as recorded in the files themselves
+const (
+	FormatFlat = iota
+	FormatJSON
+)
+
+// ExtensionJSON is the file extension used to select the JSON format
+const ExtensionJSON = ".mpsites.json"
+
+// dateLayout is the ISO 8601 layout used by the mpw clients
+const dateLayout = "2006-01-02T15:04:05Z"
+
+// Sites exported errors
+var (
+	ErrFormatUnsupported = errors.New("mpsites format is unsupported")
+	ErrMalformed         = errors.New("mpsites file is malformed")
+	ErrSiteNameEmpty     = errors.New("mpsites site name must be set")
+)
+
+// User is the root of an mpsites export.
+type User struct {
+	Fullname    string
+	KeyID       string
+	Version     string
+	ExportDate  time.Time
+	LastUsed    time.Time
+	Sites       []*Site
+	Avatar      uint32
+	Algorithm   uint32
+	DefaultType ResultType
+	Redacted    bool // site content is encrypted with the master key
+}
+
+// Site is a single site record of an mpsites export.
+type Site struct {
+	Name      string
+	LoginName string
+	Content   string // stored password, encrypted when User.Redacted
+	URL       string
+	LastUsed  time.Time
+	Questions []*Question
+	Type      ResultType
+	Counter   uint32
+	Algorithm uint32
+	Uses      uint32
+}
+
+// Question is a recovery question of a Site, Keyword is used as the keyContext.
+type Question struct {
+	Keyword string
+	Content string
+}
+
+// NewUser returns a new User with the mpw defaults set
+func NewUser(fullname string, algorithm uint32) *User {
+	now := time.Now().UTC().Truncate(time.Second)
+	return &User{
+		Fullname:    fullname,
+		Algorithm:   algorithm,
+		DefaultType: ResultTypeTemplateLong,
+		ExportDate:  now,
+		LastUsed:    now,
+		Redacted:    true,
+	}
+}
+
+// Site returns the named Site or nil if it does not exist
+func (u *User) Site(name string) *Site {
+	for _, s := range u.Sites {
+		if s.Name == name {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// AddSite adds s to the User's sites replacing any existing site with the same name
+func (u *User) AddSite(s *Site) error {
+	if s.Name == "" {
+		return ErrSiteNameEmpty
+	}
+
+	for i, us := range u.Sites {
+		if us.Name == s.Name {
+			u.Sites[i] = s
+			return nil
+		}
+	}
+	u.Sites = append(u.Sites, s)
+	u.sortSites()
+
+	return nil
+}
+
+func (u *User) sortSites() {
+	sort.Sort(byName(u.Sites))
+}
+
+// byName sorts sites by name
+type byName []*Site
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// IsJSON reports if filename denotes the JSON format
+func IsJSON(filename string) bool {
+	return strings.HasSuffix(strings.ToLower(filename), ExtensionJSON)
+}
+
+// Load reads the given mpsites file, the format is chosen by its extension
+func Load(filename string) (*User, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if IsJSON(filename) {
+		return ReadJSON(f)
+	}
+
+	return ReadFlat(f)
+}
+
+// Save writes u to the given mpsites file, the format is chosen by its extension
+func Save(filename string, u *User) (err error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+
+	return Write(f, u, IsJSON(filename))
+}
+
+// Write writes u to w in the flat or JSON format
+func Write(w io.Writer, u *User, asJSON bool) error {
+	if asJSON {
+		return WriteJSON(w, u)
+	}
+
+	return WriteFlat(w, u)
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format(dateLayout)
+}
+
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(dateLayout, s)
+}
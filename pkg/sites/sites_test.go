@@ -0,0 +1,155 @@
+//==============================================================================
+// This file is part of go-MasterPassword
+// Copyright (c) 2017, TerraTech
+// Development funded by FutureQuest, Inc.
+//   https://www.FutureQuest.net
+//
+// go-MasterPassword is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-MasterPassword is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You can find a copy of the GNU General Public License in the
+// LICENSE file.  Alternatively, see <http://www.gnu.org/licenses/>.
+//==============================================================================
+
+package sites_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/TerraTech/go-MasterPassword/pkg/sites"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02T15:04:05Z", s)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func expectedUser() *sites.User {
+	return &sites.User{
+		Fullname:    "Robert Lee Mitchell",
+		KeyID:       "98EEF4D1DF46D849574A82A03C3177056B15DFFCA29BB3899DE4628453675302",
+		ExportDate:  date("2017-09-05T12:00:00Z"),
+		Algorithm:   3,
+		DefaultType: sites.ResultTypeTemplateLong,
+		Redacted:    true,
+		Sites: []*sites.Site{
+			{Name: "bank.example.com", Type: sites.ResultTypeTemplatePIN, Counter: 1, Algorithm: 3, Uses: 2,
+				LastUsed: date("2017-09-04T08:30:00Z")},
+			{Name: "github.com", LoginName: "robert", Type: sites.ResultTypeTemplateMaximum, Counter: 3, Algorithm: 3, Uses: 12,
+				LastUsed: date("2017-09-05T11:00:00Z")},
+			{Name: "legacy.example.com", Content: "BdNmO7yRbl4FyT+7Nw0yqQ==", Type: sites.ResultTypeStatefulPersonal, Counter: 1, Algorithm: 2, Uses: 1,
+				LastUsed: date("2017-08-01T10:00:00Z")},
+			{Name: "masterpasswordapp.com", Type: sites.ResultTypeTemplateLong, Counter: 1, Algorithm: 3, Uses: 3,
+				LastUsed: date("2017-09-05T12:00:00Z")},
+		},
+	}
+}
+
+func TestLoadFlat(t *testing.T) {
+	expected := expectedUser()
+	expected.Version = "2.6"
+
+	u, err := sites.Load("../../files/test.mpsites")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, expected, u)
+}
+
+func TestLoadJSON(t *testing.T) {
+	expected := expectedUser()
+	expected.LastUsed = date("2017-09-05T12:00:00Z")
+	expected.Sites[0].Questions = []*sites.Question{{Keyword: "first pet"}, {Keyword: "mother"}}
+	expected.Sites[1].URL = "https://github.com/login"
+
+	u, err := sites.Load("../../files/test.mpsites.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, expected, u)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, fn := range []string{"../../files/test.mpsites", "../../files/test.mpsites.json"} {
+		u, err := sites.Load(fn)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		var buf bytes.Buffer
+		isJSON := sites.IsJSON(fn)
+		assert.NoError(t, sites.Write(&buf, u, isJSON))
+
+		var u2 *sites.User
+		if isJSON {
+			u2, err = sites.ReadJSON(&buf)
+		} else {
+			u2, err = sites.ReadFlat(&buf)
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, u, u2, fn)
+	}
+}
+
+func TestReadFlatBad(t *testing.T) {
+	bad := []string{
+		// no header
+		"2017-09-05T12:00:00Z  3  17:3:1  \tsite\t\n",
+		// unsupported format
+		"##\n# Format: 2\n##\n",
+		// bad type
+		"##\n# Format: 1\n##\n2017-09-05T12:00:00Z  3  xx:3:1  \tsite\t\n",
+		// missing site name
+		"##\n# Format: 1\n##\n2017-09-05T12:00:00Z  3  17:3:1  \t\t\n",
+	}
+
+	for _, b := range bad {
+		_, err := sites.ReadFlat(bytes.NewBufferString(b))
+		assert.Error(t, err, b)
+	}
+}
+
+func TestAddSite(t *testing.T) {
+	u := sites.NewUser("Robert Lee Mitchell", 3)
+	assert.Equal(t, sites.ErrSiteNameEmpty, u.AddSite(&sites.Site{}))
+
+	assert.NoError(t, u.AddSite(&sites.Site{Name: "zzz.com", Counter: 1}))
+	assert.NoError(t, u.AddSite(&sites.Site{Name: "aaa.com", Counter: 1}))
+	assert.NoError(t, u.AddSite(&sites.Site{Name: "zzz.com", Counter: 2}))
+	if assert.Len(t, u.Sites, 2) {
+		assert.Equal(t, "aaa.com", u.Sites[0].Name)
+		assert.Equal(t, uint32(2), u.Site("zzz.com").Counter)
+	}
+	assert.Nil(t, u.Site("noexist.com"))
+}
+
+func TestResultType(t *testing.T) {
+	pt, err := sites.ResultTypeTemplatePhrase.PasswordType()
+	assert.NoError(t, err)
+	assert.Equal(t, "phrase", pt)
+
+	_, err = sites.ResultTypeStatefulPersonal.PasswordType()
+	assert.Equal(t, sites.ErrResultTypeNotTemplate, err)
+	_, err = sites.ResultType(69).PasswordType()
+	assert.Equal(t, sites.ErrResultTypeInvalid, err)
+
+	rt, err := sites.PasswordTypeToResultType("x")
+	assert.NoError(t, err)
+	assert.Equal(t, sites.ResultTypeTemplateMaximum, rt)
+	_, err = sites.PasswordTypeToResultType("overdrive")
+	assert.Error(t, err)
+}